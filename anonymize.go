@@ -0,0 +1,197 @@
+package jcfg
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// AnonymizeOptions selects which kinds of values Anonymize replaces.
+type AnonymizeOptions struct {
+	HostNames bool // values of host-name statements
+	Addresses bool // IPv4 and IPv6 addresses and prefixes anywhere in the tree
+	Secrets   bool // passwords, keys and other secrets
+	Comments  bool // remove comments and annotations, which may mention anything
+}
+
+// secretKeywords are keywords whose following value is a secret, whether
+// they start the statement ("encrypted-password ...") or appear among its
+// values ("pre-shared-key ascii-text ...").
+var secretKeywords = map[string]bool{
+	"ascii-text":         true,
+	"authentication-key": true,
+	"encrypted-password": true,
+	"hexadecimal":        true,
+	"secret":             true,
+	"simple-password":    true,
+}
+
+// Anonymize returns a copy of the tree with the values selected by opts
+// replaced so that the configuration can be shared safely. Host names and
+// addresses are replaced with stable pseudonyms: every occurrence of the
+// same value within the tree maps to the same pseudonym so references
+// between statements still line up. Addresses are mapped into the
+// benchmarking (198.18.0.0/15) and documentation (2001:db8::/32) ranges,
+// keeping any prefix length. Addresses within quoted values and comments,
+// such as a description "uplink to 10.1.1.1", are replaced too. Secrets
+// are replaced with an empty string. Comments are kept unless
+// opts.Comments removes them. The original tree is not modified.
+func (t *Tree) Anonymize(opts AnonymizeOptions) *Tree {
+	a := &anonymizer{
+		opts:  opts,
+		hosts: make(map[string]string),
+		addrs: make(map[netip.Addr]netip.Addr),
+	}
+	c := t.Clone()
+	WalkWithOptions(c.Root, WalkOptions{IncludeComments: true}, func(_ []string, n Node) bool {
+		switch n := n.(type) {
+		case *ValueNode:
+			n.Keyword = a.value(n.Keyword)
+			a.values(n.Keyword, n.Values)
+			if opts.Comments {
+				n.Comments = nil
+			}
+		case *SectionNode:
+			n.Keyword = a.value(n.Keyword)
+			a.values(n.Keyword, n.Values)
+			if opts.Comments {
+				n.Comments = nil
+				n.Nodes = statements(n.Nodes)
+			}
+		case *CommentNode:
+			n.Text = a.text(n.Text)
+		}
+		return true
+	})
+	return c
+}
+
+type anonymizer struct {
+	opts  AnonymizeOptions
+	hosts map[string]string
+	addrs map[netip.Addr]netip.Addr
+	next4 netip.Addr
+	next6 netip.Addr
+}
+
+// values anonymizes the values of a statement in place.
+func (a *anonymizer) values(keyword string, values []string) {
+	for i, v := range values {
+		switch {
+		case a.opts.Secrets && secretKeywords[keyword]:
+			values[i] = `""`
+		case a.opts.HostNames && keyword == "host-name":
+			values[i] = a.host(v)
+		default:
+			values[i] = a.value(v)
+		}
+		keyword = v
+	}
+}
+
+// value returns the keyword or value s with its addresses anonymized. An
+// unquoted s is replaced only if it is an address as a whole, while the
+// addresses anywhere within a quoted s are replaced.
+func (a *anonymizer) value(s string) string {
+	if isQuoted(s) {
+		return a.text(s)
+	}
+	return a.address(s)
+}
+
+// text returns s with every address or prefix within it replaced by its
+// pseudonym if addresses are being anonymized.
+func (a *anonymizer) text(s string) string {
+	if !a.opts.Addresses {
+		return s
+	}
+	var b strings.Builder
+	for s != "" {
+		i := strings.IndexFunc(s, isAddressChar)
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		j := strings.IndexFunc(s, func(r rune) bool { return !isAddressChar(r) })
+		if j < 0 {
+			j = len(s)
+		}
+		// Leave punctuation ending a sentence, as in "via 10.1.1.1.".
+		word := strings.TrimRight(s[:j], ".:")
+		if _, _, ok := parseAddress(word); ok {
+			b.WriteString(a.address(word))
+			s = s[len(word):]
+			continue
+		}
+		b.WriteString(s[:j])
+		s = s[j:]
+	}
+	return b.String()
+}
+
+// isAddressChar reports whether r can be part of an IPv4 or IPv6 address
+// or prefix.
+func isAddressChar(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '.' || r == ':' || r == '/'
+}
+
+func (a *anonymizer) host(name string) string {
+	p, ok := a.hosts[name]
+	if !ok {
+		p = fmt.Sprintf("host%d", len(a.hosts)+1)
+		a.hosts[name] = p
+	}
+	return p
+}
+
+// address returns the pseudonym for s if it is an address or prefix and
+// addresses are being anonymized, otherwise s is returned unchanged.
+func (a *anonymizer) address(s string) string {
+	if !a.opts.Addresses {
+		return s
+	}
+	addr, bits, ok := parseAddress(s)
+	if !ok {
+		return s
+	}
+	p, ok := a.addrs[addr]
+	if !ok {
+		if addr.Is4() {
+			if !a.next4.IsValid() {
+				a.next4 = netip.MustParseAddr("198.18.0.0")
+			}
+			a.next4 = a.next4.Next()
+			p = a.next4
+		} else {
+			if !a.next6.IsValid() {
+				a.next6 = netip.MustParseAddr("2001:db8::")
+			}
+			a.next6 = a.next6.Next()
+			p = a.next6
+		}
+		a.addrs[addr] = p
+	}
+	if bits >= 0 {
+		return netip.PrefixFrom(p, bits).String()
+	}
+	return p.String()
+}
+
+// parseAddress parses an address with an optional prefix length. bits is
+// -1 if s has no prefix length.
+func parseAddress(s string) (addr netip.Addr, bits int, ok bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Addr{}, 0, false
+		}
+		return p.Addr(), p.Bits(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, 0, false
+	}
+	return addr, -1, true
+}
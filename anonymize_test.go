@@ -0,0 +1,190 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+const anonymizeConfig = `system {
+    host-name edge1;
+    root-authentication {
+        encrypted-password "$6$abc$def";
+    }
+    ntp {
+        server 10.1.1.1;
+    }
+}
+interfaces {
+    lo0 {
+        unit 0 {
+            family inet {
+                address 10.1.1.1/32;
+            }
+            family inet6 {
+                address 2001:db8:1::1/128;
+            }
+        }
+    }
+}
+security {
+    ike {
+        policy P {
+            pre-shared-key ascii-text "$9$secret";
+        }
+    }
+}
+policy-options {
+    prefix-list LOOPBACKS {
+        10.1.1.1/32;
+        10.2.2.2/32;
+    }
+}
+`
+
+func TestAnonymize(t *testing.T) {
+	tree, err := Parse("anonymize", anonymizeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `system {
+    host-name host1;
+    root-authentication {
+        encrypted-password "";
+    }
+    ntp {
+        server 198.18.0.1;
+    }
+}
+interfaces {
+    lo0 {
+        unit 0 {
+            family inet {
+                address 198.18.0.1/32;
+            }
+            family inet6 {
+                address 2001:db8::1/128;
+            }
+        }
+    }
+}
+security {
+    ike {
+        policy P {
+            pre-shared-key ascii-text "";
+        }
+    }
+}
+policy-options {
+    prefix-list LOOPBACKS {
+        198.18.0.1/32;
+        198.18.0.2/32;
+    }
+}
+`
+	anon := tree.Anonymize(AnonymizeOptions{HostNames: true, Addresses: true, Secrets: true})
+	if result := anon.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	if result := tree.String(); result != anonymizeConfig {
+		t.Errorf("original tree modified: got\n%s", result)
+	}
+}
+
+func TestAnonymizeOptions(t *testing.T) {
+	tree, err := Parse("anonymize", anonymizeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anon := tree.Anonymize(AnonymizeOptions{Secrets: true})
+
+	for _, test := range []struct {
+		path  []string
+		value string
+	}{
+		{[]string{"system", "host-name"}, "edge1"},
+		{[]string{"system", "ntp", "server"}, "10.1.1.1"},
+		{[]string{"system", "root-authentication", "encrypted-password"}, `""`},
+	} {
+		n, ok := anon.Get(test.path...)
+		if !ok {
+			t.Fatalf("%v: not found", test.path)
+		}
+		if v := n.(*ValueNode).Values[0]; v != test.value {
+			t.Errorf("%v: got %s, expected %s", test.path, v, test.value)
+		}
+	}
+}
+
+func TestAnonymizeComments(t *testing.T) {
+	const input = `## Last changed: 2024-01-01 by admin
+system {
+    /* edge1.example.net, reachable via 10.1.1.1 */
+    host-name edge1;
+    ## SECRET-DATA
+    encrypted-password "$6$abc$def"; # old one was hunter2
+}
+interfaces {
+    ge-0/0/0 {
+        description "uplink to 10.1.1.1 and 2001:db8:1::1.";
+        unit 0 {
+            family inet {
+                address 10.1.1.1/30;
+            }
+        }
+        # peer 10.9.9.9/30
+    }
+}
+`
+	tree, err := Parse("anonymize", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anon := tree.Anonymize(AnonymizeOptions{Addresses: true})
+	n, ok := anon.Get("interfaces", "ge-0/0/0", "description")
+	if !ok {
+		t.Fatal("description not found")
+	}
+	if v, expected := n.(*ValueNode).Values[0], `"uplink to 198.18.0.1 and 2001:db8::1."`; v != expected {
+		t.Errorf("description: got %s, expected %s", v, expected)
+	}
+	result := anon.String()
+	for _, leak := range []string{"10.1.1.1", "10.9.9.9", "2001:db8:1::1"} {
+		if strings.Contains(result, leak) {
+			t.Errorf("address %s not anonymized:\n%s", leak, result)
+		}
+	}
+	if !strings.Contains(result, "# peer 198.18.0.2/30") {
+		t.Errorf("comment address not anonymized:\n%s", result)
+	}
+
+	anon = tree.Anonymize(AnonymizeOptions{HostNames: true, Addresses: true, Secrets: true, Comments: true})
+	const expected = `system {
+    host-name host1;
+    encrypted-password "";
+}
+interfaces {
+    ge-0/0/0 {
+        description "uplink to 198.18.0.1 and 2001:db8::1.";
+        unit 0 {
+            family inet {
+                address 198.18.0.1/30;
+            }
+        }
+    }
+}
+`
+	if result := anon.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	var b strings.Builder
+	if err := anon.WriteConfig(&b, PrintOptions{Preserve: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"edge1", "hunter2", "admin", "SECRET", "10."} {
+		if strings.Contains(b.String(), leak) {
+			t.Errorf("%q leaked:\n%s", leak, b.String())
+		}
+	}
+}
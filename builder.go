@@ -0,0 +1,127 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Builder constructs a Tree one statement at a time. Its methods return
+// the Builder so calls can be chained:
+//
+//	t, err := NewBuilder().
+//		Section("system").
+//		Leaf("host-name", "r1").
+//		Section("syslog").
+//		Section("file", "messages").
+//		Leaf("any", "notice").
+//		Build()
+//
+// Statements are added to the innermost open section until End closes
+// it. Values are quoted as by Quote, so they are given as they should
+// read rather than as they are written in a configuration. The first
+// error, such as an invalid keyword, is reported by Build and any calls
+// after it have no effect.
+type Builder struct {
+	t     *Tree
+	stack []*SectionNode // open sections, the root first
+	err   error
+}
+
+// NewBuilder returns a Builder for an empty tree.
+func NewBuilder() *Builder {
+	t := New("builder")
+	return &Builder{t: t, stack: []*SectionNode{t.Root}}
+}
+
+// Section opens a new section with keyword and identifying values. The
+// following statements are added to it until End is called.
+func (b *Builder) Section(keyword string, values ...string) *Builder {
+	if !b.check(keyword) {
+		return b
+	}
+	s := b.t.newSection(NoPos, keyword, quoteValues(values))
+	b.top().append(s)
+	b.stack = append(b.stack, s)
+	return b
+}
+
+// Leaf adds a leaf statement with keyword and values, or a flag if there
+// are no values, to the open section.
+func (b *Builder) Leaf(keyword string, values ...string) *Builder {
+	if b.check(keyword) {
+		b.top().append(b.t.newValue(NoPos, keyword, quoteValues(values)))
+	}
+	return b
+}
+
+// List adds a leaf statement with keyword whose values are written as a
+// list, as in "members [ a b ];", to the open section.
+func (b *Builder) List(keyword string, values ...string) *Builder {
+	if b.check(keyword) {
+		v := b.t.newValue(NoPos, keyword, quoteValues(values))
+		v.List = true
+		b.top().append(v)
+	}
+	return b
+}
+
+// End closes the innermost open section.
+func (b *Builder) End() *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.stack) == 1 {
+		b.err = fmt.Errorf("jcfg: builder: End without an open section")
+		return b
+	}
+	b.stack = b.stack[:len(b.stack)-1]
+	return b
+}
+
+// Build returns the tree, closing any sections still open, or the first
+// error encountered while building it.
+func (b *Builder) Build() (*Tree, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.t, nil
+}
+
+func (b *Builder) top() *SectionNode {
+	return b.stack[len(b.stack)-1]
+}
+
+// check reports whether a statement with keyword can be added, recording
+// an error if keyword is not valid.
+func (b *Builder) check(keyword string) bool {
+	if b.err != nil {
+		return false
+	}
+	if !validKeyword(keyword) {
+		b.err = fmt.Errorf("jcfg: builder: invalid keyword %q", keyword)
+		return false
+	}
+	return true
+}
+
+// validKeyword reports whether s lexes as a single keyword.
+func validKeyword(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isValueChar(r) {
+			return false
+		}
+	}
+	// A word ending in its only ':' lexes as a modifier.
+	return strings.Index(s, ":") != len(s)-1
+}
+
+func quoteValues(values []string) []string {
+	q := make([]string, len(values))
+	for i, v := range values {
+		q[i] = Quote(v)
+	}
+	return q
+}
@@ -0,0 +1,81 @@
+package jcfg
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tree, err := NewBuilder().
+		Section("system").
+		Leaf("host-name", "r1").
+		Section("syslog").
+		Section("file", "messages").
+		Leaf("any", "notice").
+		Leaf("authorization", "info").
+		End().
+		Section("user", "*").
+		Leaf("any", "emergency").
+		End().
+		End().
+		Section("login").
+		Leaf("message", "authorized use only").
+		End().
+		End().
+		Section("vlans").
+		Section("users").
+		Leaf("vlan-id", "10").
+		List("members", "ge-0/0/1", "ge-0/0/2").
+		Leaf("disable").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `system {
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+            authorization info;
+        }
+        user "*" {
+            any emergency;
+        }
+    }
+    login {
+        message "authorized use only";
+    }
+}
+vlans {
+    users {
+        vlan-id 10;
+        members [ ge-0/0/1 ge-0/0/2 ];
+        disable;
+    }
+}
+`
+	if result := tree.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	parsed, err := Parse("expected", expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.EqualWithOptions(parsed, EqualOptions{Ordered: true}) {
+		t.Errorf("built tree not equal to parsed tree")
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	for name, b := range map[string]*Builder{
+		"empty keyword":   NewBuilder().Leaf(""),
+		"invalid keyword": NewBuilder().Section("system").Leaf("host name", "r1"),
+		"modifier":        NewBuilder().Leaf("inactive:"),
+		"extra end":       NewBuilder().Section("system").End().End(),
+		"sticky":          NewBuilder().End().Section("system"),
+	} {
+		if tree, err := b.Build(); err == nil {
+			t.Errorf("%s: expected error; got tree\n%s", name, tree)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package jcfg
+
+import "strings"
+
+// CommentKind is the syntax a comment is written in.
+type CommentKind int
+
+const (
+	CommentKindHash       CommentKind = iota // "#" to the end of the line
+	CommentKindAnnotation                    // "##" annotation to the end of the line
+	CommentKindLine                          // "//" to the end of the line
+	CommentKindBlock                         // "/*" up to "*/", possibly over several lines
+)
+
+// Kind returns the syntax the comment is written in.
+func (c *CommentNode) Kind() CommentKind {
+	switch {
+	case isAnnotation(c.Text):
+		return CommentKindAnnotation
+	case strings.HasPrefix(c.Text, hashComment):
+		return CommentKindHash
+	case strings.HasPrefix(c.Text, leftBlockComment):
+		return CommentKindBlock
+	}
+	return CommentKindLine
+}
+
+// CommentStyle selects the syntax comments are written in by WriteConfig.
+type CommentStyle int
+
+const (
+	CommentStylePreserve CommentStyle = iota // keep the syntax of each comment
+	CommentStyleHash                         // "# text"
+	CommentStyleLine                         // "// text"
+	CommentStyleBlock                        // "/* text */"
+)
+
+// restyle returns the comment text rewritten in style. Annotations and
+// block comments spanning several lines are returned unchanged, as is a
+// comment whose text would end a block comment early.
+func restyle(text string, style CommentStyle) string {
+	if style == CommentStylePreserve || isAnnotation(text) || strings.Contains(text, "\n") {
+		return text
+	}
+	var body string
+	switch {
+	case strings.HasPrefix(text, leftBlockComment):
+		body = strings.TrimSuffix(text[len(leftBlockComment):], rightBlockComment)
+	case strings.HasPrefix(text, hashComment):
+		body = text[len(hashComment):]
+	default:
+		body = strings.TrimPrefix(text, lineComment)
+	}
+	body = strings.TrimSpace(body)
+	prefix := ""
+	switch style {
+	case CommentStyleHash:
+		prefix = hashComment
+	case CommentStyleLine:
+		prefix = lineComment
+	case CommentStyleBlock:
+		if strings.Contains(body, rightBlockComment) {
+			return text
+		}
+		if body == "" {
+			return leftBlockComment + " " + rightBlockComment
+		}
+		return leftBlockComment + " " + body + " " + rightBlockComment
+	default:
+		return text
+	}
+	if body == "" {
+		return prefix
+	}
+	return prefix + " " + body
+}
+
+// Comment is a comment of a tree along with where it sits.
+type Comment struct {
+	Text string // the comment exactly as it appeared in the input
+	// Path is the path of the statement the comment is attached to, or
+	// of the section a trailing comment is at the end of, as passed to a
+	// Walk function. It is empty for a comment at the end of the
+	// configuration that is not followed by any statement.
+	Path       []string
+	Kind       CommentKind
+	Attachment CommentAttachment
+	Node       *CommentNode
+}
+
+// Comments returns every comment of the tree in the order they appear,
+// both those attached to a statement and those standing alone at the end
+// of a section.
+func (t *Tree) Comments() []Comment {
+	var comments []Comment
+	WalkWithOptions(t.Root, WalkOptions{IncludeComments: true}, func(path []string, n Node) bool {
+		if c, ok := n.(*CommentNode); ok {
+			comments = append(comments, Comment{
+				Text:       c.Text,
+				Path:       path,
+				Kind:       c.Kind(),
+				Attachment: c.Attachment,
+				Node:       c,
+			})
+		}
+		return true
+	})
+	return comments
+}
@@ -0,0 +1,71 @@
+package jcfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	const input = `## Last changed: 2024-01-01 10:00:00 UTC
+# the system
+system {
+    /* managed by
+       automation */
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+            // left over
+        }
+    }
+}
+# end of file
+`
+	tree, err := Parse("comments", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Comment
+	for _, c := range tree.Comments() {
+		if c.Node == nil || c.Node.Text != c.Text {
+			t.Errorf("%q: got node %v", c.Text, c.Node)
+		}
+		c.Node = nil
+		got = append(got, c)
+	}
+	expected := []Comment{
+		{"## Last changed: 2024-01-01 10:00:00 UTC", []string{"system"}, CommentKindAnnotation, CommentAnnotation, nil},
+		{"# the system", []string{"system"}, CommentKindHash, CommentLeading, nil},
+		{"/* managed by\n       automation */", []string{"system", "host-name"}, CommentKindBlock, CommentLeading, nil},
+		{"// left over", []string{"system", "syslog", "file messages"}, CommentKindLine, CommentTrailing, nil},
+		{"# end of file", nil, CommentKindHash, CommentTrailing, nil},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", got, expected)
+	}
+}
+
+func TestRestyle(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		style    CommentStyle
+		expected string
+	}{
+		{"# note", CommentStyleLine, "// note"},
+		{"#note", CommentStyleBlock, "/* note */"},
+		{"// note", CommentStyleHash, "# note"},
+		{"/* note */", CommentStyleLine, "// note"},
+		{"/*note*/", CommentStyleHash, "# note"},
+		{"#", CommentStyleBlock, "/* */"},
+		{"/* */", CommentStyleHash, "#"},
+		{"# note", CommentStylePreserve, "# note"},
+		// unchanged
+		{"## annotation", CommentStyleBlock, "## annotation"},
+		{"/* two\n   lines */", CommentStyleHash, "/* two\n   lines */"},
+		{"# a */ b", CommentStyleBlock, "# a */ b"},
+	} {
+		if got := restyle(test.text, test.style); got != test.expected {
+			t.Errorf("%q in style %d: got %q, expected %q", test.text, test.style, got, test.expected)
+		}
+	}
+}
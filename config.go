@@ -0,0 +1,117 @@
+package jcfg
+
+// Config provides typed access to well-known parts of a Junos
+// configuration held in a Tree.
+type Config struct {
+	*Tree
+}
+
+// NewConfig returns a Config for the configuration in t.
+func NewConfig(t *Tree) *Config {
+	return &Config{Tree: t}
+}
+
+// SchedulerMap is a class-of-service scheduler map.
+type SchedulerMap struct {
+	Name     string
+	Bindings []SchedulerBinding
+}
+
+// SchedulerBinding assigns a scheduler to a forwarding class within a
+// scheduler map.
+type SchedulerBinding struct {
+	ForwardingClass string
+	Scheduler       string
+}
+
+// SchedulerMaps returns the scheduler maps under
+// "class-of-service scheduler-maps" in the order they are configured.
+// Each binding is read from a statement such as
+// "forwarding-class best-effort scheduler be-sched;".
+func (c *Config) SchedulerMaps() []SchedulerMap {
+	n, ok := c.Get("class-of-service", "scheduler-maps")
+	if !ok {
+		return nil
+	}
+	s, ok := n.(*SectionNode)
+	if !ok {
+		return nil
+	}
+	var maps []SchedulerMap
+	for _, n := range s.Nodes {
+		if v, ok := n.(*ValueNode); ok && len(v.Values) == 0 {
+			// a map without bindings
+			maps = append(maps, SchedulerMap{Name: v.Keyword})
+			continue
+		}
+		ms, ok := n.(*SectionNode)
+		if !ok {
+			continue
+		}
+		m := SchedulerMap{Name: ms.Keyword}
+		for _, n := range ms.Nodes {
+			v, ok := n.(*ValueNode)
+			if !ok || v.Keyword != "forwarding-class" || len(v.Values) != 3 || v.Values[1] != "scheduler" {
+				continue
+			}
+			m.Bindings = append(m.Bindings, SchedulerBinding{
+				ForwardingClass: Unquote(v.Values[0]),
+				Scheduler:       Unquote(v.Values[2]),
+			})
+		}
+		maps = append(maps, m)
+	}
+	return maps
+}
+
+// EventPolicy is an event policy that runs operational mode commands
+// when events occur.
+type EventPolicy struct {
+	Name     string
+	Events   []string
+	Commands []string // commands run by the execute-commands action
+}
+
+// EventPolicies returns the policies under "event-options" in the order
+// they are configured. Events are read from "events" statements, written
+// either as a list or one per statement, and commands from the
+// "then execute-commands commands" section, whose statements are the
+// quoted commands themselves. Quoting is removed from names, events and
+// commands.
+func (c *Config) EventPolicies() []EventPolicy {
+	n, ok := c.Get("event-options")
+	if !ok {
+		return nil
+	}
+	s, ok := n.(*SectionNode)
+	if !ok {
+		return nil
+	}
+	var policies []EventPolicy
+	for _, n := range s.Nodes {
+		ps, ok := n.(*SectionNode)
+		if !ok || ps.Keyword != "policy" || len(ps.Values) != 1 {
+			continue
+		}
+		p := EventPolicy{Name: Unquote(ps.Values[0])}
+		for _, n := range ps.Nodes {
+			if v, ok := n.(*ValueNode); ok && v.Keyword == "events" {
+				p.Events = append(p.Events, v.Unquoted()...)
+			}
+		}
+		if n, ok := c.Get("event-options", ps.name(), "then", "execute-commands", "commands"); ok {
+			switch n := n.(type) {
+			case *SectionNode:
+				for _, cmd := range statements(n.Nodes) {
+					if v, ok := cmd.(*ValueNode); ok {
+						p.Commands = append(p.Commands, Unquote(v.Keyword))
+					}
+				}
+			case *ValueNode:
+				p.Commands = append(p.Commands, n.Unquoted()...)
+			}
+		}
+		policies = append(policies, p)
+	}
+	return policies
+}
@@ -0,0 +1,76 @@
+package jcfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchedulerMaps(t *testing.T) {
+	const input = `class-of-service {
+    scheduler-maps {
+        M {
+            forwarding-class best-effort scheduler be-sched;
+            forwarding-class expedited-forwarding scheduler "ef sched";
+        }
+        EMPTY;
+    }
+}`
+	tree, err := Parse("cos", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SchedulerMap{{
+		Name: "M",
+		Bindings: []SchedulerBinding{
+			{ForwardingClass: "best-effort", Scheduler: "be-sched"},
+			{ForwardingClass: "expedited-forwarding", Scheduler: "ef sched"},
+		},
+	}, {
+		Name: "EMPTY",
+	}}
+	if maps := NewConfig(tree).SchedulerMaps(); !reflect.DeepEqual(maps, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", maps, expected)
+	}
+
+	if maps := NewConfig(New("empty")).SchedulerMaps(); maps != nil {
+		t.Errorf("got %+v from an empty configuration", maps)
+	}
+}
+
+func TestEventPolicies(t *testing.T) {
+	const input = `event-options {
+    policy LINK-DOWN {
+        events [ snmp_trap_link_down ui_commit ];
+        then {
+            execute-commands {
+                commands {
+                    "show interfaces terse";
+                    "show log messages | match \"link down\"";
+                }
+                output-filename link.log;
+            }
+        }
+    }
+    policy NO-ACTION {
+        events ui_login_event;
+    }
+}`
+	tree, err := Parse("events", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []EventPolicy{{
+		Name:     "LINK-DOWN",
+		Events:   []string{"snmp_trap_link_down", "ui_commit"},
+		Commands: []string{"show interfaces terse", `show log messages | match "link down"`},
+	}, {
+		Name:   "NO-ACTION",
+		Events: []string{"ui_login_event"},
+	}}
+	if policies := NewConfig(tree).EventPolicies(); !reflect.DeepEqual(policies, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", policies, expected)
+	}
+	if s := tree.String(); s != input+"\n" {
+		t.Errorf("got\n%s\nexpected\n%s", s, input)
+	}
+}
@@ -0,0 +1,274 @@
+package jcfg
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalOptions controls how configuration statements are stored in Go
+// values by UnmarshalWithOptions.
+type UnmarshalOptions struct {
+	// DisallowUnknownFields makes a statement with no matching struct
+	// field an error rather than being ignored.
+	DisallowUnknownFields bool
+}
+
+// Unmarshal parses the configuration in data and stores the statements in
+// the struct pointed to by v. Statements without a matching field are
+// ignored. See UnmarshalWithOptions for details.
+func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, UnmarshalOptions{})
+}
+
+// UnmarshalWithOptions parses the configuration in data and stores the
+// statements in the struct pointed to by v, using the struct tags
+// described by Marshal to match statements to fields.
+//
+// A section is stored in a struct, or in a map keyed by the identifying
+// value of the section. A leaf statement is stored in a field of a
+// scalar type by converting its value, unquoted as by Unquote, to the
+// type of the field; a string field is given all of the values joined by
+// spaces. Values of types implementing encoding.TextUnmarshaler are
+// converted by UnmarshalText. A flag statement sets a bool field to true.
+// A slice has an element appended for each statement with its keyword,
+// or for each value of a list statement such as "members [ a b ];".
+// Pointers are allocated as needed.
+//
+// A value that cannot be converted to the type of its field is an error
+// giving the path of the statement.
+func UnmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jcfg: Unmarshal of non-pointer %v", reflect.TypeOf(v))
+	}
+	if rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("jcfg: Unmarshal of non-struct type %v", rv.Type())
+	}
+	t, err := Parse("unmarshal", string(data))
+	if err != nil {
+		return err
+	}
+	d := &decoder{opts: opts}
+	return d.section(nil, t.Root, rv.Elem())
+}
+
+type decoder struct {
+	opts UnmarshalOptions
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// section stores the statements of s, which is at path, in the struct v.
+func (d *decoder) section(path []string, s *SectionNode, v reflect.Value) error {
+	// rest is the field with the keyword "*", if any, which holds the
+	// statements not matching any other field.
+	fields := make(map[string]structField)
+	var rest *structField
+	for _, f := range structFields(v.Type()) {
+		if f.keyword == "*" {
+			rest = &f
+			continue
+		}
+		fields[f.keyword] = f
+	}
+	for _, n := range statements(s.Nodes) {
+		p := appendPath(path, mergeKey(n))
+		keyword := mergeKey(n)
+		if s, ok := n.(*SectionNode); ok {
+			keyword = s.Keyword
+		}
+		f, ok := fields[keyword]
+		if !ok && rest != nil {
+			f, ok = *rest, true
+		}
+		switch {
+		case ok:
+			if err := d.field(p, f, n, v.FieldByIndex(f.index)); err != nil {
+				return err
+			}
+		case d.opts.DisallowUnknownFields:
+			return fmt.Errorf("jcfg: %s: unknown statement %q for %s", strings.Join(p, " "), keyword, v.Type())
+		}
+	}
+	return nil
+}
+
+// field stores the statement n, which is at path, in v, the value of the
+// field f or an element of it.
+func (d *decoder) field(path []string, f structField, n Node, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && !v.Type().Implements(textUnmarshalerType) {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.field(path, f, n, v.Elem())
+	}
+	if isTextUnmarshaler(v) {
+		return d.scalar(path, f, n, v)
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		return d.mapEntry(path, f, n, v)
+	case reflect.Slice:
+		if l, ok := n.(*ValueNode); ok && (l.List || len(l.Values) > 1) {
+			for _, val := range l.Values {
+				e := reflect.New(v.Type().Elem()).Elem()
+				if err := setScalar(path, f, e, Unquote(val)); err != nil {
+					return err
+				}
+				v.Set(reflect.Append(v, e))
+			}
+			return nil
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		if err := d.field(path, f, n, e); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, e))
+		return nil
+	case reflect.Struct:
+		switch n := n.(type) {
+		case *SectionNode:
+			return d.section(path, n, v)
+		case *ValueNode:
+			if len(n.Values) == 0 {
+				return nil
+			}
+		}
+		return typeError(path, f, n, v)
+	case reflect.Bool:
+		switch n := n.(type) {
+		case *SectionNode:
+			v.SetBool(true)
+			return nil
+		case *ValueNode:
+			if len(n.Values) == 0 {
+				v.SetBool(true)
+				return nil
+			}
+		}
+	}
+	return d.scalar(path, f, n, v)
+}
+
+// scalar stores the values of the leaf statement n in the scalar v.
+func (d *decoder) scalar(path []string, f structField, n Node, v reflect.Value) error {
+	l, ok := n.(*ValueNode)
+	if !ok || len(l.Values) == 0 || (len(l.Values) > 1 && v.Kind() != reflect.String) {
+		return typeError(path, f, n, v)
+	}
+	return setScalar(path, f, v, strings.Join(l.Unquoted(), " "))
+}
+
+// mapEntry stores the statement n in the map v keyed by the identifying
+// value of n or, for a map with the keyword "*", by its keyword.
+func (d *decoder) mapEntry(path []string, f structField, n Node, v reflect.Value) error {
+	var keyword string
+	var values []string
+	switch n := n.(type) {
+	case *ValueNode:
+		keyword, values = n.Keyword, n.Values
+	case *SectionNode:
+		keyword, values = n.Keyword, n.Values
+	}
+	key := keyword
+	if f.keyword != "*" {
+		if len(values) == 0 {
+			return typeError(path, f, n, v)
+		}
+		key, values = Unquote(values[0]), values[1:]
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	k := reflect.New(v.Type().Key()).Elem()
+	if err := setScalar(path, f, k, key); err != nil {
+		return err
+	}
+	e := reflect.New(v.Type().Elem()).Elem()
+	if old := v.MapIndex(k); old.IsValid() {
+		e.Set(old)
+	}
+	// The entry is the statement without its key.
+	var entry Node
+	switch n := n.(type) {
+	case *ValueNode:
+		c := *n
+		c.Values = values
+		entry = &c
+	case *SectionNode:
+		c := *n
+		c.Values = values
+		entry = &c
+	}
+	if err := d.field(path, f, entry, e); err != nil {
+		return err
+	}
+	v.SetMapIndex(k, e)
+	return nil
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) ||
+		v.Kind() == reflect.Ptr && v.Type().Implements(textUnmarshalerType)
+}
+
+// setScalar converts s to the type of v and stores it there.
+func setScalar(path []string, f structField, v reflect.Value, s string) error {
+	if isTextUnmarshaler(v) {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+		} else {
+			v = v.Addr()
+		}
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("jcfg: %s: cannot unmarshal %q into field %s: %v", strings.Join(path, " "), s, f.name, err)
+		}
+		return nil
+	}
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, v.Type().Bits()); err == nil {
+			v.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		var x float64
+		if x, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(x)
+			return nil
+		}
+	}
+	return fmt.Errorf("jcfg: %s: cannot unmarshal %q into field %s of type %s", strings.Join(path, " "), s, f.name, v.Type())
+}
+
+// typeError returns the error for a statement whose form does not suit
+// the type of its field, such as a section for an int.
+func typeError(path []string, f structField, n Node, v reflect.Value) error {
+	kind := "leaf statement"
+	if n.Type() == NodeSection {
+		kind = "section"
+	}
+	return fmt.Errorf("jcfg: %s: cannot unmarshal %s into field %s of type %s", strings.Join(path, " "), kind, f.name, v.Type())
+}
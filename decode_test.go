@@ -0,0 +1,139 @@
+package jcfg
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	const input = `system {
+    host-name "r1";
+    domain-name example.com;
+    services {
+        ssh;
+        netconf { ssh; }
+    }
+    file messages {
+        any notice;
+        archive {
+            size 10m;
+            files 3;
+        }
+    }
+    name-server 192.0.2.53;
+    name-server 2001:db8::53;
+    ntp { server 192.0.2.123; }
+}
+interfaces {
+    ge-0/0/0 {
+        description "to core";
+        unit 0 {
+            address 10.0.0.1/24;
+            address 10.0.1.1/24;
+        }
+    }
+    ge-0/0/1 {
+        mtu 9192;
+    }
+}
+vlans {
+    users {
+        vlan-id 10;
+        members [ ge-0/0/2 ge-0/0/3 ];
+    }
+}
+`
+	var cfg testConfig
+	if err := Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testConfig{
+		System: testSystem{
+			HostName:   "r1",
+			DomainName: "example.com",
+			Services:   testServices{SSH: true},
+			NameServer: []netip.Addr{
+				netip.MustParseAddr("192.0.2.53"),
+				netip.MustParseAddr("2001:db8::53"),
+			},
+		},
+		Interfaces: testInterfaces{All: map[string]testInterface{
+			"ge-0/0/0": {
+				Description: "to core",
+				Unit: map[int]testUnit{0: {Address: []netip.Prefix{
+					netip.MustParsePrefix("10.0.0.1/24"),
+					netip.MustParsePrefix("10.0.1.1/24"),
+				}}},
+			},
+			"ge-0/0/1": {MTU: 9192},
+		}},
+		VLANs: &testVLANs{All: map[string]testVLAN{
+			"users": {ID: 10, Members: []string{"ge-0/0/2", "ge-0/0/3"}},
+		}},
+	}
+	log := testLog{Any: "notice"}
+	log.Archive.Size = "10m"
+	log.Archive.Files = 3
+	expected.System.Syslog = map[string]testLog{"messages": log}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", cfg, expected)
+	}
+
+	// A marshaled struct unmarshals to the same value.
+	b, err := Marshal(&expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again testConfig
+	if err := Unmarshal(b, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, expected) {
+		t.Errorf("round trip: got\n\t%+v\nexpected\n\t%+v", again, expected)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	const input = "system { host-name r1; services { ssh; } ntp { server 192.0.2.123; } }"
+	var cfg testConfig
+	if err := Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unknown statement without strict mode: %v", err)
+	}
+	err := UnmarshalWithOptions([]byte(input), &cfg, UnmarshalOptions{DisallowUnknownFields: true})
+	if err == nil || !strings.Contains(err.Error(), "system ntp:") {
+		t.Errorf("got error %v, expected an error for system ntp", err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		v     interface{}
+		err   string
+	}{
+		{"interfaces { ge-0/0/1 { mtu big; } }", &testConfig{},
+			`jcfg: interfaces ge-0/0/1 mtu: cannot unmarshal "big" into field MTU of type int`},
+		{"interfaces { ge-0/0/1 { unit zero { } } }", &testConfig{},
+			`jcfg: interfaces ge-0/0/1 unit zero: cannot unmarshal "zero" into field Unit of type int`},
+		{"system { host-name { r1; } }", &testConfig{},
+			`jcfg: system host-name: cannot unmarshal section into field HostName of type string`},
+		{"system { name-server 192.0.2.256; }", &testConfig{},
+			`jcfg: system name-server: cannot unmarshal "192.0.2.256" into field NameServer: ParseAddr("192.0.2.256"): IPv4 field has value >255`},
+		{"system { host-name r1; }", testConfig{},
+			`jcfg: Unmarshal of non-pointer jcfg.testConfig`},
+		{"system {", &testConfig{},
+			`unmarshal:1:1: unexpected EOF, expected '}'`},
+	} {
+		err := Unmarshal([]byte(test.input), test.v)
+		if err == nil {
+			t.Errorf("%q: expected error; got none", test.input)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("%q: got error\n\t%v\nexpected\n\t%s", test.input, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package jcfg
+
+import (
+	"strconv"
+)
+
+// ChangeOp is the kind of change a Change describes.
+type ChangeOp int
+
+const (
+	Added    ChangeOp = iota + 1 // the statement is only in the new tree
+	Removed                      // the statement is only in the old tree
+	Modified                     // the statement is in both with different values
+	Moved                        // the ordered statement is in both at a different position
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Modified:
+		return "Modified"
+	case Moved:
+		return "Moved"
+	}
+	return "ChangeOp(" + strconv.Itoa(int(op)) + ")"
+}
+
+// Change is a single difference between two trees.
+type Change struct {
+	Op   ChangeOp
+	Path []string // path to the statement, addressed as with Get
+	Old  []string // values in the old tree; nil for sections
+	New  []string // values in the new tree; nil for sections
+	// Comment is set when the change is to the comments of the statement
+	// at Path (or of the section itself for comments not attached to a
+	// statement) rather than to the statement. Old and New then hold the
+	// text of the comments.
+	Comment bool
+	// Modifier is set when the change is to the modifier of the statement
+	// at Path, such as "inactive" being added or removed, rather than to
+	// the statement. Old and New then hold the modifier, or nothing if
+	// there is none.
+	Modifier bool
+}
+
+// DiffOptions controls the behavior of DiffWithOptions.
+type DiffOptions struct {
+	// IncludeComments reports changes to comments. By default comments
+	// are ignored.
+	IncludeComments bool
+	// OrderedSections are the patterns, as for Match, of the sections
+	// whose order among their siblings is significant. A nil slice means
+	// DefaultOrderedSections; an empty one compares no order at all.
+	OrderedSections [][]string
+}
+
+// DefaultOrderedSections are the patterns, as for Match, of the sections
+// whose order is significant unless another set is given: the terms of
+// firewall filters and of policy statements, which are evaluated from the
+// first to the last.
+var DefaultOrderedSections = [][]string{
+	{"firewall", "**", "filter", "*", "term"},
+	{"policy-options", "policy-statement", "*", "term"},
+}
+
+// orderedSection reports whether the section at path is matched by one of
+// patterns, or by DefaultOrderedSections if patterns is nil.
+func orderedSection(patterns [][]string, path []string) bool {
+	if patterns == nil {
+		patterns = DefaultOrderedSections
+	}
+	for _, pattern := range patterns {
+		if matchPath(pattern, pathFields(path), equalString) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the changes needed to turn tree a into tree b. Sibling
+// statements are matched regardless of their order: sections by keyword
+// and identifying values, and leaves by keyword and the order in which
+// leaves with the same keyword appear. An added or removed section is a
+// single change; the statements below it are not listed separately. A
+// change to the modifier of a statement in both trees is reported
+// separately from any change to its values, as a change with Modifier set.
+//
+// The order of the sections matched by DefaultOrderedSections, such as
+// firewall filter terms, is significant: a section in both trees that is
+// out of order relative to the others is reported as Moved. The fewest
+// sections needed to explain the new order are reported.
+//
+// Changes are ordered by the position of the statement in a, with
+// statements only in b following those of the same section in a.
+func Diff(a, b *Tree) []Change {
+	return DiffWithOptions(a, b, DiffOptions{})
+}
+
+// DiffWithOptions is like Diff but with options controlling what is
+// compared.
+func DiffWithOptions(a, b *Tree, opts DiffOptions) []Change {
+	d := &differ{opts: opts}
+	d.section(nil, a.Root, b.Root)
+	return d.changes
+}
+
+// differ walks two trees in step, matching the statements of each pair
+// of sections, so it descends itself rather than through Walk, which
+// visits a single tree.
+type differ struct {
+	opts    DiffOptions
+	changes []Change
+}
+
+func (d *differ) section(path []string, a, b *SectionNode) {
+	if d.opts.IncludeComments {
+		d.comments(path, standaloneComments(a.Nodes), standaloneComments(b.Nodes))
+	}
+	bKeys := make(map[string]Node)
+	for _, k := range keyStatements(b.Nodes) {
+		bKeys[k.key] = k.node
+	}
+	moved := d.moved(path, a, b)
+	matched := make(map[string]bool)
+	for _, k := range keyStatements(a.Nodes) {
+		bn, ok := bKeys[k.key]
+		if !ok {
+			d.add(Removed, path, k.node)
+			continue
+		}
+		matched[k.key] = true
+		if moved[k.key] {
+			d.changes = append(d.changes, Change{Op: Moved, Path: appendPath(path, mergeKey(k.node))})
+		}
+		d.statement(path, k.node, bn)
+	}
+	for _, k := range keyStatements(b.Nodes) {
+		if !matched[k.key] {
+			d.add(Added, path, k.node)
+		}
+	}
+}
+
+// statement compares two statements with the same key.
+func (d *differ) statement(path []string, a, b Node) {
+	switch an := a.(type) {
+	case *ValueNode:
+		bn := b.(*ValueNode)
+		p := appendPath(path, an.Keyword)
+		if d.opts.IncludeComments {
+			d.comments(p, an.Comments, bn.Comments)
+		}
+		d.modifier(p, an.Modifier, bn.Modifier)
+		if !equalValues(p, an.Values, bn.Values) {
+			d.changes = append(d.changes, Change{Op: Modified, Path: p, Old: an.Values, New: bn.Values})
+		}
+	case *SectionNode:
+		bn := b.(*SectionNode)
+		p := appendPath(path, an.name())
+		if d.opts.IncludeComments {
+			d.comments(p, an.Comments, bn.Comments)
+		}
+		d.modifier(p, an.Modifier, bn.Modifier)
+		d.section(p, an, bn)
+	}
+}
+
+// moved returns the keys of the ordered sections of a, which is at path,
+// that are out of order in b. The sections left in place are the longest
+// common subsequence of the ordered sections both have.
+func (d *differ) moved(path []string, a, b *SectionNode) map[string]bool {
+	ordered := func(s *SectionNode) []string {
+		var keys []string
+		for _, k := range keyStatements(s.Nodes) {
+			if c, ok := k.node.(*SectionNode); ok && orderedSection(d.opts.OrderedSections, appendPath(path, c.name())) {
+				keys = append(keys, k.key)
+			}
+		}
+		return keys
+	}
+	as, bs := ordered(a), ordered(b)
+	as, bs = filterKeys(as, bs), filterKeys(bs, as)
+	if len(as) < 2 {
+		return nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of as[i:]
+	// and bs[j:].
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	moved := make(map[string]bool)
+	for i, j := 0, 0; i < len(as); {
+		switch {
+		case j < len(bs) && as[i] == bs[j]:
+			i++
+			j++
+		case j < len(bs) && lcs[i][j+1] >= lcs[i+1][j]:
+			j++
+		default:
+			moved[as[i]] = true
+			i++
+		}
+	}
+	return moved
+}
+
+// filterKeys returns the keys of a that are also in b, in order.
+func filterKeys(a, b []string) []string {
+	var keys []string
+	for _, k := range a {
+		if contains(b, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// add records a statement only present in one of the trees.
+func (d *differ) add(op ChangeOp, path []string, n Node) {
+	c := Change{Op: op}
+	switch n := n.(type) {
+	case *ValueNode:
+		c.Path = appendPath(path, n.Keyword)
+		if op == Added {
+			c.New = n.Values
+		} else {
+			c.Old = n.Values
+		}
+	case *SectionNode:
+		c.Path = appendPath(path, n.name())
+	}
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) comments(path []string, a, b []*CommentNode) {
+	before, after := commentTexts(a), commentTexts(b)
+	if equalValues(path, before, after) {
+		return
+	}
+	op := Modified
+	switch {
+	case len(before) == 0:
+		op = Added
+	case len(after) == 0:
+		op = Removed
+	}
+	d.changes = append(d.changes, Change{Op: op, Path: copyStrings(path), Old: before, New: after, Comment: true})
+}
+
+// modifier records a change to the modifier of the statement at path.
+func (d *differ) modifier(path []string, a, b string) {
+	if a == b {
+		return
+	}
+	c := Change{Op: Modified, Path: copyStrings(path), Modifier: true}
+	if a != "" {
+		c.Old = []string{a}
+	}
+	if b != "" {
+		c.New = []string{b}
+	}
+	switch {
+	case a == "":
+		c.Op = Added
+	case b == "":
+		c.Op = Removed
+	}
+	d.changes = append(d.changes, c)
+}
+
+// keyedStatement is a statement with the key used to match it against its
+// counterpart in another tree.
+type keyedStatement struct {
+	key  string
+	node Node
+}
+
+// keyStatements returns the statements of nodes with their keys. Leaves
+// sharing a keyword are told apart by their order.
+func keyStatements(nodes []Node) []keyedStatement {
+	var keyed []keyedStatement
+	seen := make(map[string]int)
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *ValueNode:
+			keyed = append(keyed, keyedStatement{"leaf " + n.Keyword + " " + strconv.Itoa(seen[n.Keyword]), n})
+			seen[n.Keyword]++
+		case *SectionNode:
+			keyed = append(keyed, keyedStatement{"section " + n.name(), n})
+		}
+	}
+	return keyed
+}
+
+func standaloneComments(nodes []Node) []*CommentNode {
+	var comments []*CommentNode
+	for _, n := range nodes {
+		if c, ok := n.(*CommentNode); ok {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func commentTexts(comments []*CommentNode) []string {
+	var texts []string
+	for _, c := range comments {
+		texts = append(texts, c.Text)
+	}
+	return texts
+}
@@ -0,0 +1,172 @@
+package jcfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+const diffModified = `# updated
+system {
+    syslog {
+        user "*" {
+            any emergency;
+        }
+        file messages {
+            any warning;
+            authorization info;
+        }
+        file security {
+            any any;
+        }
+    }
+    host-name r1;
+}
+`
+
+func TestDiff(t *testing.T) {
+	a, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("modified", diffModified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Op: Modified, Path: []string{"system", "syslog", "file messages", "any"}, Old: []string{"notice"}, New: []string{"warning"}},
+		{Op: Removed, Path: []string{"system", "syslog", "file interactive-commands"}},
+		{Op: Added, Path: []string{"system", "syslog", "file security"}},
+		{Op: Added, Path: []string{"system", "host-name"}, New: []string{"r1"}},
+	}
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", changes, expected)
+	}
+
+}
+
+func TestDiffOrdered(t *testing.T) {
+	a, err := Parse("a", `firewall {
+    family inet {
+        filter protect-re {
+            term allow-ssh { then accept; }
+            term allow-bgp { then accept; }
+            term deny-all { then discard; }
+        }
+    }
+}
+policy-options {
+    policy-statement export { term one { then accept; } term two { then reject; } }
+}
+interfaces { ge-0/0/0 { mtu 9192; } ge-0/0/1 { mtu 9192; } }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", `firewall {
+    family inet {
+        filter protect-re {
+            term allow-bgp { then accept; }
+            term allow-ssh { then accept; }
+            term deny-all { then discard; }
+        }
+    }
+}
+policy-options {
+    policy-statement export { term two { then reject; } term one { then accept; } }
+}
+interfaces { ge-0/0/1 { mtu 9192; } ge-0/0/0 { mtu 9192; } }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Op: Moved, Path: []string{"firewall", "family inet", "filter protect-re", "term allow-bgp"}},
+		{Op: Moved, Path: []string{"policy-options", "policy-statement export", "term two"}},
+	}
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", changes, expected)
+	}
+	if changes := DiffWithOptions(a, b, DiffOptions{OrderedSections: [][]string{}}); len(changes) != 0 {
+		t.Errorf("got\n\t%+v\nexpected no changes with no ordered sections", changes)
+	}
+	expected = []Change{
+		{Op: Moved, Path: []string{"interfaces", "ge-0/0/1"}},
+	}
+	if changes := DiffWithOptions(a, b, DiffOptions{OrderedSections: [][]string{{"interfaces", "*"}}}); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", changes, expected)
+	}
+}
+
+func TestDiffRepeatedLeaves(t *testing.T) {
+	a, err := Parse("a", "members a; members b; members c;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", "members a; members d;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Change{
+		{Op: Modified, Path: []string{"members"}, Old: []string{"b"}, New: []string{"d"}},
+		{Op: Removed, Path: []string{"members"}, Old: []string{"c"}},
+	}
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", changes, expected)
+	}
+}
+
+func TestDiffModifiers(t *testing.T) {
+	a, err := Parse("a", "system { host-name r1; inactive: ntp { server 10.0.0.1; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", "system { inactive: host-name r1; ntp { server 10.0.0.1; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Change{
+		{Op: Added, Path: []string{"system", "host-name"}, New: []string{"inactive"}, Modifier: true},
+		{Op: Removed, Path: []string{"system", "ntp"}, Old: []string{"inactive"}, Modifier: true},
+	}
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", changes, expected)
+	}
+	if a.Equal(b) {
+		t.Error("Equal reported trees differing in modifiers as equal")
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	a, err := Parse("a", "system { host-name r1; ports { console; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", "system {\n    # reordered\n    ports {\n        console;\n    }\n    host-name r1;\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(a, b); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiffComments(t *testing.T) {
+	a, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("modified", diffModified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := DiffWithOptions(a, b, DiffOptions{IncludeComments: true})
+	expected := Change{Op: Added, Path: []string{"system"}, New: []string{"# updated"}, Comment: true}
+	if len(changes) == 0 || !reflect.DeepEqual(changes[0], expected) {
+		t.Errorf("got\n\t%+v\nexpected first change\n\t%+v", changes, expected)
+	}
+	if len(changes) != 5 {
+		t.Errorf("got %d changes, expected 5", len(changes))
+	}
+}
@@ -0,0 +1,166 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines DiffText shows around each
+// change.
+const diffContext = 3
+
+// DiffText returns a unified diff of the configurations of trees a and b,
+// as printed by Format with SortSections set so that sections that only
+// differ in order between the trees are not shown as changed. Lines only
+// in a are prefixed with "-", lines only in b with "+", and each group of
+// changes is shown with up to three lines of context. The result is
+// empty if the configurations print the same.
+func DiffText(a, b *Tree) string {
+	opts := PrintOptions{SortSections: true}
+	edits := lineEdits(lines(a.Format(opts)), lines(b.Format(opts)))
+
+	var out strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// The hunk starts diffContext lines before the change and runs
+		// until there are more than 2*diffContext unchanged lines.
+		start := max(i-diffContext, 0)
+		end := i
+		for unchanged := 0; end < len(edits) && unchanged <= 2*diffContext; end++ {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > i && edits[end-1].op == ' ' {
+			end--
+		}
+		end = min(end+diffContext, len(edits))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", a.Name, b.Name)
+		}
+		hunk := edits[start:end]
+		aStart, bStart := edits[start].a, edits[start].b
+		var aCount, bCount int
+		for _, e := range hunk {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, e := range hunk {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of lines of a hunk, where start is the
+// index of the first line of the hunk, as in a unified diff.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// lines splits s into lines without their line endings.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineEdit is a line of a diff: ' ' for a line in both texts, '-' for a
+// line only in the first and '+' for a line only in the second. a and b
+// are the number of lines of each text before it.
+type lineEdit struct {
+	op   byte
+	line string
+	a, b int
+}
+
+// lineEdits returns the shortest edit script turning a into b found by
+// the Myers diff algorithm.
+func lineEdits(a, b []string) []lineEdit {
+	n, m := len(a), len(b)
+	off := n + m + 1
+	v := make([]int, 2*off+1)
+	// trace[d] holds v[-d:d+1] before step d, which is enough to retrace
+	// the path taken to reach the end.
+	var trace [][]int
+	d := 0
+search:
+	for ; ; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []lineEdit
+	x, y := n, m
+	for ; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, lineEdit{' ', a[x], x, y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, lineEdit{'+', b[y], x, y})
+		} else {
+			x--
+			edits = append(edits, lineEdit{'-', a[x], x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, lineEdit{' ', a[x], x, y})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,78 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffText(t *testing.T) {
+	a, err := Parse("a.config", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b.config", strings.Replace(factoryConfig, "any notice;", "any warning;", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `--- a.config
++++ b.config
+@@ -4,7 +4,7 @@
+             interactive-commands any;
+         }
+         file messages {
+-            any notice;
++            any warning;
+             authorization info;
+         }
+         user "*" {
+`
+	if got := DiffText(a, b); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+	if got := DiffText(a, a); got != "" {
+		t.Errorf("got\n%s\nexpected no difference", got)
+	}
+}
+
+func TestDiffTextHunks(t *testing.T) {
+	var before, after []string
+	for i := 0; i < 20; i++ {
+		before = append(before, "line"+string(rune('a'+i))+";")
+	}
+	after = append(after, "first;")
+	after = append(after, before[:10]...)
+	after = append(after, before[11:]...)
+	after = append(after, "last;")
+	a, err := Parse("a", strings.Join(before, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", strings.Join(after, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `--- a
++++ b
+@@ -1,3 +1,4 @@
++first;
+ linea;
+ lineb;
+ linec;
+@@ -8,7 +9,6 @@
+ lineh;
+ linei;
+ linej;
+-linek;
+ linel;
+ linem;
+ linen;
+@@ -18,3 +18,4 @@
+ liner;
+ lines;
+ linet;
++last;
+`
+	if got := DiffText(a, b); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+}
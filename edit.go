@@ -0,0 +1,156 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set sets the leaf statement at path to values, replacing any values it
+// already has, creating it and any missing sections along the way like
+// the Junos "set" command. With no values the leaf becomes a flag. Path
+// segments are addressed as with Get and the last segment is the keyword
+// of the leaf. Missing sections are created, splitting a segment such as
+// "file messages" into the section keyword and its identifying values.
+//
+// Set returns an error without modifying the tree if a segment other
+// than the last refers to a leaf, the last refers to a section, or any
+// segment is ambiguous.
+func (t *Tree) Set(path []string, values ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("jcfg: set: empty path")
+	}
+	// Resolve the existing part of the path before changing anything.
+	s := t.Root
+	i := 0
+	for ; i < len(path)-1; i++ {
+		n, err := s.find(path, i)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			break
+		}
+		c, ok := n.(*SectionNode)
+		if !ok {
+			return fmt.Errorf("jcfg: set %s: %q is a leaf statement, not a section", strings.Join(path, " "), path[i])
+		}
+		s = c
+	}
+	if i == len(path)-1 {
+		n, err := s.find(path, i)
+		if err != nil {
+			return err
+		}
+		switch n := n.(type) {
+		case *ValueNode:
+			n.Values = copyStrings(values)
+			return nil
+		case *SectionNode:
+			return fmt.Errorf("jcfg: set %s: %q is a section, not a leaf statement", strings.Join(path, " "), path[i])
+		}
+	}
+
+	// Validate the missing segments so that nothing is added on error.
+	segments := make([][]string, len(path)-i)
+	for j, segment := range path[i:] {
+		words, list, err := splitSetLine(segment)
+		last := i+j == len(path)-1
+		if err != nil || len(words) == 0 || list != nil || (last && len(words) != 1) {
+			return fmt.Errorf("jcfg: set %s: invalid path segment %q", strings.Join(path, " "), segment)
+		}
+		segments[j] = words
+	}
+	for _, words := range segments[:len(segments)-1] {
+		c := t.newSection(NoPos, words[0], words[1:])
+		s.append(c)
+		s = c
+	}
+	s.append(t.newValue(NoPos, path[len(path)-1], copyStrings(values)))
+	return nil
+}
+
+// find returns the statement in s addressed by path[i], or nil if there
+// is none, and an error if the segment is ambiguous.
+func (s *SectionNode) find(path []string, i int) (Node, error) {
+	switch matches := s.lookup(path[i]); len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("jcfg: %s: %q matches more than one statement", strings.Join(path, " "), path[i])
+}
+
+// DeleteOptions controls the behavior of DeleteWithOptions.
+type DeleteOptions struct {
+	// DeleteEmpty also removes any section left without statements by the
+	// deletion, repeating up the tree. By default emptied sections are
+	// kept, matching Junos which keeps empty stanzas.
+	DeleteEmpty bool
+}
+
+// Delete removes the statement at path, including everything below it if
+// it is a section, and reports whether anything was removed. Path segments
+// are addressed as with Get; an ambiguous or missing path removes nothing.
+func (t *Tree) Delete(path ...string) bool {
+	return t.DeleteWithOptions(DeleteOptions{}, path...)
+}
+
+// DeleteWithOptions is like Delete but with options controlling what
+// happens to the sections containing the deleted statement.
+func (t *Tree) DeleteWithOptions(opts DeleteOptions, path ...string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	sections := []*SectionNode{t.Root}
+	for _, segment := range path[:len(path)-1] {
+		n, ok := sections[len(sections)-1].child(segment)
+		if !ok {
+			return false
+		}
+		s, ok := n.(*SectionNode)
+		if !ok {
+			return false
+		}
+		sections = append(sections, s)
+	}
+	n, ok := sections[len(sections)-1].child(path[len(path)-1])
+	if !ok {
+		return false
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		s := sections[i]
+		s.remove(n)
+		if !opts.DeleteEmpty || i == 0 || len(statements(s.Nodes)) > 0 {
+			break
+		}
+		n = s
+	}
+	return true
+}
+
+// insertAfter inserts n into the nodes of s directly after prev.
+func (s *SectionNode) insertAfter(prev, n Node) {
+	for i, c := range s.Nodes {
+		if c == prev {
+			s.Nodes = append(s.Nodes, nil)
+			copy(s.Nodes[i+2:], s.Nodes[i+1:])
+			s.Nodes[i+1] = n
+			return
+		}
+	}
+	s.append(n)
+}
+
+// remove removes n from the nodes of s, clearing the vacated slot so the
+// backing array holds no reference to it.
+func (s *SectionNode) remove(n Node) {
+	for i, c := range s.Nodes {
+		if c == n {
+			copy(s.Nodes[i:], s.Nodes[i+1:])
+			s.Nodes[len(s.Nodes)-1] = nil
+			s.Nodes = s.Nodes[:len(s.Nodes)-1]
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package jcfg
+
+import (
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tree, err := Parse("set", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create a leaf below new sections
+	if err := tree.Set([]string{"system", "host-name"}, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"system", "syslog", "file security", "authorization"}, "any"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"system", "login", "message"}, `"hello world"`); err != nil {
+		t.Fatal(err)
+	}
+	// overwrite an existing leaf
+	if err := tree.Set([]string{"system", "syslog", "file messages", "any"}, "warning"); err != nil {
+		t.Fatal(err)
+	}
+	// multiple values and flags
+	if err := tree.Set([]string{"system", "syslog", "user", "match"}, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"system", "ports", "console", "insecure"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `system {
+    syslog {
+        file messages {
+            any warning;
+            authorization info;
+        }
+        file interactive-commands {
+            interactive-commands any;
+        }
+        user "*" {
+            any emergency;
+            match a b;
+        }
+        file security {
+            authorization any;
+        }
+    }
+    host-name r1;
+    login {
+        message "hello world";
+    }
+    ports {
+        console {
+            insecure;
+        }
+    }
+}
+`
+	if result := tree.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		path []string
+	}{
+		{"empty path", nil},
+		{"leaf as section", []string{"system", "syslog", "file messages", "any", "notice"}},
+		{"section as leaf", []string{"system", "syslog"}},
+		{"ambiguous", []string{"system", "syslog", "file", "any"}},
+		{"invalid keyword", []string{"system", "host name"}},
+		{"invalid section", []string{"system", `user "unterminated`, "class"}},
+	} {
+		tree, err := Parse("set", factoryConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tree.Set(test.path, "value")
+		if err == nil {
+			t.Errorf("%s: expected error; got none", test.name)
+			continue
+		}
+		t.Logf("%s: got expected error: %v", test.name, err)
+		if result := tree.String(); result != factoryConfig {
+			t.Errorf("%s: tree modified on error:\n%s", test.name, result)
+		}
+	}
+}
+
+type deleteTest struct {
+	name    string
+	path    []string
+	opts    DeleteOptions
+	deleted bool
+	result  string
+}
+
+var deleteTests = []deleteTest{
+	{"leaf", []string{"system", "syslog", "file messages", "authorization"}, DeleteOptions{}, true,
+		"system {\n    syslog {\n        file messages {\n            any notice;\n        }\n        user \"*\" {\n            any emergency;\n        }\n    }\n}\n"},
+	{"last leaf keeps section", []string{"system", "syslog", "user", "any"}, DeleteOptions{}, true,
+		"system {\n    syslog {\n        file messages {\n            any notice;\n            authorization info;\n        }\n        user \"*\" {\n        }\n    }\n}\n"},
+	{"last leaf delete empty", []string{"system", "syslog", "user", "any"}, DeleteOptions{DeleteEmpty: true}, true,
+		"system {\n    syslog {\n        file messages {\n            any notice;\n            authorization info;\n        }\n    }\n}\n"},
+	{"section", []string{"system", "syslog", "file messages"}, DeleteOptions{}, true,
+		"system {\n    syslog {\n        user \"*\" {\n            any emergency;\n        }\n    }\n}\n"},
+	{"subtree", []string{"system", "syslog"}, DeleteOptions{}, true, "system {\n}\n"},
+	{"top-level section", []string{"system"}, DeleteOptions{DeleteEmpty: true}, true, ""},
+	{"missing", []string{"system", "services"}, DeleteOptions{}, false, deleteConfig},
+	{"below leaf", []string{"system", "syslog", "user", "any", "emergency"}, DeleteOptions{}, false, deleteConfig},
+	{"empty path", nil, DeleteOptions{}, false, deleteConfig},
+}
+
+const deleteConfig = `system {
+    syslog {
+        file messages {
+            any notice;
+            authorization info;
+        }
+        user "*" {
+            any emergency;
+        }
+    }
+}
+`
+
+func TestDelete(t *testing.T) {
+	for _, test := range deleteTests {
+		tree, err := Parse(test.name, deleteConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted := tree.DeleteWithOptions(test.opts, test.path...); deleted != test.deleted {
+			t.Errorf("%s: got deleted %v, expected %v", test.name, deleted, test.deleted)
+		}
+		if result := tree.String(); result != test.result {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, result, test.result)
+		}
+	}
+}
+
+func TestDeleteCompacts(t *testing.T) {
+	tree, err := Parse("compact", "a; b; c;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := tree.Root.Nodes
+	if !tree.Delete("a") {
+		t.Fatal("a not deleted")
+	}
+	if len(tree.Root.Nodes) != 2 || nodes[2] != nil {
+		t.Errorf("backing array not cleared after delete: %v", nodes)
+	}
+}
+
+func TestDeleteAmbiguous(t *testing.T) {
+	tree, err := Parse("ambiguous", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Delete("system", "syslog", "file") {
+		t.Errorf("ambiguous path deleted")
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("tree modified:\n%s", result)
+	}
+}
@@ -0,0 +1,282 @@
+package jcfg
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the configuration for v, which must be a struct or a
+// pointer to one, in the Junos curly-brace format.
+//
+// Each exported field becomes one or more statements named by the keyword
+// in its "jcfg" struct tag, or by the lowercased field name if it has
+// none. A tag of "-" skips the field and an anonymous struct field
+// without a tag has its fields treated as if they were in the outer
+// struct. Following the keyword the tag may list options separated by
+// commas: "list" writes a slice as a single list statement such as
+// "members [ a b ];" rather than repeating the statement for each
+// element, and "omitempty" skips a field holding the zero value.
+//
+// Strings, numbers and values implementing encoding.TextMarshaler are
+// written as a leaf statement with a single value, quoted as by Quote if
+// needed. A bool is written as a flag statement when true and omitted
+// when false. A struct is written as a section. A map writes a section,
+// or for scalar elements a leaf, for each of its entries with the map key
+// as the identifying value, as in "file messages { ... }". Entries are
+// written in key order. A map field with the keyword "*" instead uses the
+// key as the keyword of each statement, which suits sections such as
+// "interfaces" whose children are named by their keyword. Nil pointers,
+// maps and slices are omitted.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("jcfg: Marshal of nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jcfg: Marshal of non-struct type %v", reflect.TypeOf(v))
+	}
+	t := New("marshal")
+	if err := encodeStruct(t.Root, nil, rv); err != nil {
+		return nil, err
+	}
+	return []byte(t.String()), nil
+}
+
+// structField is a field of a struct mapped to configuration statements.
+type structField struct {
+	name      string // Go name of the field
+	index     []int  // index sequence for reflect.Value.FieldByIndex
+	keyword   string
+	list      bool
+	omitEmpty bool
+}
+
+// structFields returns the fields of the struct type t to be marshaled,
+// in order, with those of untagged anonymous struct fields inlined.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("jcfg")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct {
+			for _, inner := range structFields(f.Type) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		sf := structField{name: f.Name, index: []int{i}, keyword: strings.ToLower(f.Name)}
+		opts := strings.Split(tag, ",")
+		if opts[0] != "" {
+			sf.keyword = opts[0]
+		}
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "list":
+				sf.list = true
+			case "omitempty":
+				sf.omitEmpty = true
+			}
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// encodeStruct appends the statements for the fields of v to s, which is
+// at path.
+func encodeStruct(s *SectionNode, path []string, v reflect.Value) error {
+	for _, f := range structFields(v.Type()) {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if err := encodeField(s, path, f, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeField appends the statements for the field f holding v to s.
+func encodeField(s *SectionNode, path []string, f structField, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	p := appendPath(path, f.keyword)
+	if isScalar(v) {
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				s.append(s.tr.newValue(NoPos, f.keyword, nil))
+			}
+			return nil
+		}
+		val, err := scalarString(p, v)
+		if err != nil {
+			return err
+		}
+		s.append(s.tr.newValue(NoPos, f.keyword, []string{val}))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		c := s.tr.newSection(NoPos, f.keyword, nil)
+		s.append(c)
+		return encodeStruct(c, p, v)
+	case reflect.Map:
+		return encodeMap(s, path, f, v)
+	case reflect.Slice, reflect.Array:
+		if f.list {
+			values := []string{}
+			for i := 0; i < v.Len(); i++ {
+				e := indirect(v.Index(i))
+				if !e.IsValid() || !isScalar(e) {
+					return fmt.Errorf("jcfg: %s: list elements must be strings or numbers, not %s", strings.Join(p, " "), v.Type().Elem())
+				}
+				val, err := scalarString(p, e)
+				if err != nil {
+					return err
+				}
+				values = append(values, val)
+			}
+			l := s.tr.newValue(NoPos, f.keyword, values)
+			l.List = true
+			s.append(l)
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeField(s, path, f, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("jcfg: %s: cannot marshal %s", strings.Join(p, " "), v.Type())
+}
+
+// encodeMap appends a statement to s for each entry of the map v.
+func encodeMap(s *SectionNode, path []string, f structField, v reflect.Value) error {
+	for _, k := range sortedKeys(v) {
+		key, err := scalarString(appendPath(path, f.keyword), k)
+		if err != nil {
+			return err
+		}
+		keyword, ids := f.keyword, []string{key}
+		if keyword == "*" {
+			keyword, ids = key, nil
+		}
+		p := appendPath(path, statement(keyword, ids))
+		e := indirect(v.MapIndex(k))
+		switch {
+		case !e.IsValid():
+		case e.Kind() == reflect.Struct && !isScalar(e):
+			c := s.tr.newSection(NoPos, keyword, ids)
+			s.append(c)
+			if err := encodeStruct(c, p, e); err != nil {
+				return err
+			}
+		case isScalar(e):
+			if e.Kind() == reflect.Bool {
+				if e.Bool() {
+					s.append(s.tr.newValue(NoPos, keyword, ids))
+				}
+				continue
+			}
+			val, err := scalarString(p, e)
+			if err != nil {
+				return err
+			}
+			s.append(s.tr.newValue(NoPos, keyword, append(ids, val)))
+		default:
+			return fmt.Errorf("jcfg: %s: cannot marshal %s", strings.Join(p, " "), e.Type())
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map v in order, numerically for
+// integer keys.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		}
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	})
+	return keys
+}
+
+// indirect follows pointers and interfaces from v, returning the zero
+// Value if it ends in nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if v.Type().Implements(textMarshalerType) && v.Kind() == reflect.Ptr {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isScalar reports whether v is written as a single value.
+func isScalar(v reflect.Value) bool {
+	if v.Type().Implements(textMarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// scalarString returns the scalar v as a configuration value.
+func scalarString(path []string, v reflect.Value) (string, error) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("jcfg: %s: %v", strings.Join(path, " "), err)
+		}
+		return Quote(string(text)), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return Quote(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("jcfg: %s: cannot marshal %s", strings.Join(path, " "), v.Type())
+}
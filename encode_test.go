@@ -0,0 +1,160 @@
+package jcfg
+
+import (
+	"net/netip"
+	"testing"
+)
+
+type testConfig struct {
+	System     testSystem     `jcfg:"system"`
+	Interfaces testInterfaces `jcfg:"interfaces"`
+	VLANs      *testVLANs     `jcfg:"vlans"`
+	Ignored    string         `jcfg:"-"`
+}
+
+type testSystem struct {
+	HostName   string             `jcfg:"host-name"`
+	DomainName string             `jcfg:"domain-name,omitempty"`
+	Services   testServices       `jcfg:"services"`
+	Syslog     map[string]testLog `jcfg:"file"`
+	NameServer []netip.Addr       `jcfg:"name-server"`
+}
+
+type testServices struct {
+	SSH    bool `jcfg:"ssh"`
+	Telnet bool `jcfg:"telnet"`
+}
+
+type testLog struct {
+	Any     string `jcfg:"any"`
+	Archive struct {
+		Size  string `jcfg:"size"`
+		Files int    `jcfg:"files"`
+	} `jcfg:"archive"`
+}
+
+type testInterfaces struct {
+	All map[string]testInterface `jcfg:"*"`
+}
+
+type testInterface struct {
+	Description string           `jcfg:"description,omitempty"`
+	MTU         int              `jcfg:"mtu,omitempty"`
+	Unit        map[int]testUnit `jcfg:"unit"`
+}
+
+type testUnit struct {
+	Address []netip.Prefix `jcfg:"address"`
+}
+
+type testVLANs struct {
+	All map[string]testVLAN `jcfg:"*"`
+}
+
+type testVLAN struct {
+	ID      int      `jcfg:"vlan-id"`
+	Members []string `jcfg:"members,list"`
+}
+
+func TestMarshal(t *testing.T) {
+	cfg := testConfig{
+		System: testSystem{
+			HostName: "r1",
+			Services: testServices{SSH: true},
+			NameServer: []netip.Addr{
+				netip.MustParseAddr("192.0.2.53"),
+				netip.MustParseAddr("2001:db8::53"),
+			},
+		},
+		Interfaces: testInterfaces{All: map[string]testInterface{
+			"ge-0/0/1": {MTU: 9192},
+			"ge-0/0/0": {
+				Description: "to core",
+				Unit: map[int]testUnit{
+					10: {Address: []netip.Prefix{netip.MustParsePrefix("10.0.10.1/24")}},
+					2:  {Address: []netip.Prefix{netip.MustParsePrefix("10.0.2.1/24")}},
+				},
+			},
+		}},
+		VLANs: &testVLANs{All: map[string]testVLAN{
+			"users": {ID: 10, Members: []string{"ge-0/0/2", "ge-0/0/3"}},
+		}},
+		Ignored: "ignored",
+	}
+	cfg.System.Syslog = map[string]testLog{"messages": {Any: "notice"}}
+	log := cfg.System.Syslog["messages"]
+	log.Archive.Size = "10m"
+	log.Archive.Files = 3
+	cfg.System.Syslog["messages"] = log
+
+	const expected = `system {
+    host-name r1;
+    services {
+        ssh;
+    }
+    file messages {
+        any notice;
+        archive {
+            size 10m;
+            files 3;
+        }
+    }
+    name-server 192.0.2.53;
+    name-server 2001:db8::53;
+}
+interfaces {
+    ge-0/0/0 {
+        description "to core";
+        unit 2 {
+            address 10.0.2.1/24;
+        }
+        unit 10 {
+            address 10.0.10.1/24;
+        }
+    }
+    ge-0/0/1 {
+        mtu 9192;
+    }
+}
+vlans {
+    users {
+        vlan-id 10;
+        members [ ge-0/0/2 ge-0/0/3 ];
+    }
+}
+`
+	b, err := Marshal(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b, expected)
+	}
+	tree, err := Parse("marshal", string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != string(b) {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, b)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	for _, v := range []interface{}{
+		nil,
+		(*testConfig)(nil),
+		"system",
+		struct {
+			C chan int `jcfg:"c"`
+		}{make(chan int)},
+		struct {
+			L []testUnit `jcfg:"l,list"`
+		}{[]testUnit{{}}},
+	} {
+		if _, err := Marshal(v); err == nil {
+			t.Errorf("%#v: expected error; got none", v)
+		} else {
+			t.Logf("%#v: got expected error: %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package jcfg
+
+// EqualOptions controls what EqualWithOptions treats as significant.
+type EqualOptions struct {
+	// Ordered makes the order of the statements within each section
+	// significant.
+	Ordered bool
+	// IncludeComments makes comments significant: the comments preceding
+	// each statement and those at the end of each section must match,
+	// in order.
+	IncludeComments bool
+	// Values, if non-nil, compares the values of leaf statements instead
+	// of requiring them to match exactly. See EqualFunc.
+	Values func(path []string, a, b []string) bool
+	// OrderedSections are the patterns, as for Match, of the sections
+	// whose order among their siblings is significant even when Ordered
+	// is not set, as for DiffOptions. A nil slice means
+	// DefaultOrderedSections; an empty one compares no order at all.
+	OrderedSections [][]string
+}
+
+// Equal reports whether t and other contain the same statements with
+// exactly matching values and modifiers, regardless of the order of the
+// statements within each section, other than that of the sections
+// matched by DefaultOrderedSections such as firewall filter terms, which
+// must be in the same order as Diff requires. Comments are ignored. Use
+// EqualWithOptions to make the order or comments significant. Trees that
+// are Equal have the same Hash.
+func (t *Tree) Equal(other *Tree) bool {
+	return t.EqualWithOptions(other, EqualOptions{})
+}
+
+// EqualFunc is like Equal but uses cmp to compare the values of leaf
+// statements. cmp is called with the path of the statement, ending in its
+// keyword, and the values from t and other respectively. This allows the
+// caller to define value equality per path, for instance to compare
+// host names case-insensitively.
+func (t *Tree) EqualFunc(other *Tree, cmp func(path []string, a, b []string) bool) bool {
+	return t.EqualWithOptions(other, EqualOptions{Values: cmp})
+}
+
+// EqualWithOptions is like Equal but with options controlling what is
+// compared. When the order of statements is not significant, statements
+// repeated within a section, such as several "name-server" leaves, are
+// each matched with a different statement of other.
+func (t *Tree) EqualWithOptions(other *Tree, opts EqualOptions) bool {
+	if opts.Values == nil {
+		opts.Values = equalValues
+	}
+	return equalSection(nil, t.Root, other.Root, opts)
+}
+
+// equalSection reports whether the contents of the sections a and b,
+// which are at path, are equal.
+func equalSection(path []string, a, b *SectionNode, opts EqualOptions) bool {
+	if opts.IncludeComments && !equalComments(standaloneComments(a.Nodes), standaloneComments(b.Nodes)) {
+		return false
+	}
+	as, bs := statements(a.Nodes), statements(b.Nodes)
+	if len(as) != len(bs) {
+		return false
+	}
+	if opts.Ordered {
+		for i := range as {
+			if !equalStatement(path, as[i], bs[i], opts) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !equalValues(path, orderedNames(opts.OrderedSections, path, as), orderedNames(opts.OrderedSections, path, bs)) {
+		return false
+	}
+
+	// Match each statement of a with the first unused statement of b with
+	// the same key that is equal to it.
+	candidates := make(map[string][]Node)
+	for _, n := range bs {
+		k := mergeKey(n)
+		candidates[k] = append(candidates[k], n)
+	}
+	for _, an := range as {
+		k := mergeKey(an)
+		c := candidates[k]
+		found := false
+		for i, bn := range c {
+			if equalStatement(path, an, bn, opts) {
+				candidates[k] = append(c[:i:i], c[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// equalStatement reports whether the statements a and b, which are below
+// path, are equal.
+func equalStatement(path []string, a, b Node, opts EqualOptions) bool {
+	switch an := a.(type) {
+	case *ValueNode:
+		bn, ok := b.(*ValueNode)
+		if !ok || an.Keyword != bn.Keyword || an.Modifier != bn.Modifier {
+			return false
+		}
+		if opts.IncludeComments && !equalComments(an.Comments, bn.Comments) {
+			return false
+		}
+		return opts.Values(appendPath(path, an.Keyword), an.Values, bn.Values)
+	case *SectionNode:
+		bn, ok := b.(*SectionNode)
+		if !ok || an.name() != bn.name() || an.Modifier != bn.Modifier {
+			return false
+		}
+		if opts.IncludeComments && !equalComments(an.Comments, bn.Comments) {
+			return false
+		}
+		return equalSection(appendPath(path, an.name()), an, bn, opts)
+	}
+	return false
+}
+
+// orderedNames returns the names, in order, of the sections among the
+// statements stmts of the section at path whose order is significant
+// according to patterns, as for orderedSection.
+func orderedNames(patterns [][]string, path []string, stmts []Node) []string {
+	var names []string
+	for _, n := range stmts {
+		if s, ok := n.(*SectionNode); ok && orderedSection(patterns, appendPath(path, s.name())) {
+			names = append(names, s.name())
+		}
+	}
+	return names
+}
+
+// equalComments reports whether a and b have the same text in order.
+func equalComments(a, b []*CommentNode) bool {
+	return equalValues(nil, commentTexts(a), commentTexts(b))
+}
+
+// equalValues reports whether a and b hold exactly the same values.
+func equalValues(_ []string, a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// statements returns nodes with all comments removed.
+func statements(nodes []Node) []Node {
+	var stmts []Node
+	for _, n := range nodes {
+		if n.Type() != NodeComment {
+			stmts = append(stmts, n)
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,140 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type equalTest struct {
+	name  string
+	a, b  string
+	equal bool
+}
+
+var equalTests = []equalTest{
+	{"identical", factoryConfig, factoryConfig, true},
+	{"reformatted", "system { host-name r1; }", "system {\n    host-name r1;\n}\n", true},
+	{"comments ignored", "# hello\nsystem { host-name r1; }", "system { /* world */ host-name r1; }", true},
+	{"different value", "system { host-name r1; }", "system { host-name r2; }", false},
+	{"different keyword", "system { host-name r1; }", "system { domain-name r1; }", false},
+	{"different section name", "file messages { any notice; }", "file security { any notice; }", false},
+	{"missing statement", "system { host-name r1; ports; }", "system { host-name r1; }", false},
+	{"value vs section", "system { ports; }", "system { ports { } }", false},
+	{"reordered leaves", "system { host-name r1; domain-name example.com; }", "system { domain-name example.com; host-name r1; }", true},
+	{"reordered sections", "system { syslog { file a { any any; } file b { any notice; } } }",
+		"system { syslog { file b { any notice; } file a { any any; } } }", true},
+	{"reordered repeated leaves", "system { name-server a; name-server b; }", "system { name-server b; name-server a; }", true},
+	{"repeated leaf missing", "system { name-server a; name-server a; }", "system { name-server a; name-server b; }", false},
+	{"list order", "vlan { members [ a b ]; }", "vlan { members [ b a ]; }", false},
+	{"list or values", "vlan { members [ a b ]; }", "vlan { members a b; }", true},
+	{"reordered filter terms", "firewall { filter f { term a { then accept; } term b { then discard; } } }",
+		"firewall { filter f { term b { then discard; } term a { then accept; } } }", false},
+	{"reordered policy terms", "policy-options { policy-statement p { term a { then accept; } term b { then reject; } } }",
+		"policy-options { policy-statement p { term b { then reject; } term a { then accept; } } }", false},
+	{"terms in order among reordered statements", "firewall { filter f { term a { then accept; } interface-specific; term b { then discard; } } }",
+		"firewall { filter f { interface-specific; term a { then accept; } term b { then discard; } } }", true},
+	{"modifier", "system { inactive: host-name r1; }", "system { host-name r1; }", false},
+	{"section modifier", "inactive: system { host-name r1; }", "protect: system { host-name r1; }", false},
+}
+
+func TestEqual(t *testing.T) {
+	for _, test := range equalTests {
+		a, err := Parse(test.name, test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(test.name, test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Equal(b); got != test.equal {
+			t.Errorf("%s: got %v, expected %v", test.name, got, test.equal)
+		}
+	}
+}
+
+// TestEqualHash checks that trees that are Equal have the same Hash.
+func TestEqualHash(t *testing.T) {
+	for _, test := range equalTests {
+		a, err := Parse(test.name, test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(test.name, test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		equal, sameHash := a.Equal(b), a.Hash() == b.Hash()
+		if equal && !sameHash {
+			t.Errorf("%s: Equal but hashes differ", test.name)
+		}
+		if !equal && sameHash {
+			t.Errorf("%s: not Equal but hashes match", test.name)
+		}
+	}
+}
+
+func TestEqualFunc(t *testing.T) {
+	a, err := Parse("a", "system { host-name Router1; domain-name example-com; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", "system { host-name ROUTER1; domain-name example-com; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Equal(b) {
+		t.Errorf("Equal: expected host names differing in case to be unequal")
+	}
+
+	var paths []string
+	foldHostName := func(path []string, a, b []string) bool {
+		paths = append(paths, strings.Join(path, " "))
+		if path[len(path)-1] == "host-name" {
+			return len(a) == 1 && len(b) == 1 && strings.EqualFold(a[0], b[0])
+		}
+		return equalValues(path, a, b)
+	}
+	if !a.EqualFunc(b, foldHostName) {
+		t.Errorf("EqualFunc: expected case-insensitive host-name comparison to be equal")
+	}
+	expected := "system host-name,system domain-name"
+	if got := strings.Join(paths, ","); got != expected {
+		t.Errorf("EqualFunc: comparator called with paths %q, expected %q", got, expected)
+	}
+}
+
+func TestEqualWithOptions(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		a, b  string
+		opts  EqualOptions
+		equal bool
+	}{
+		{"ordered", "system { host-name r1; domain-name example.com; }", "system { domain-name example.com; host-name r1; }",
+			EqualOptions{Ordered: true}, false},
+		{"ordered same order", "system { host-name r1; domain-name example.com; }", "system {\n    host-name r1;\n    domain-name example.com;\n}",
+			EqualOptions{Ordered: true}, true},
+		{"comments", "system { /* note */ host-name r1; }", "system { host-name r1; }", EqualOptions{IncludeComments: true}, false},
+		{"trailing comments", "system { host-name r1; # end\n}", "system { host-name r1; # other\n}", EqualOptions{IncludeComments: true}, false},
+		{"same comments reordered", "system { # a\n host-name r1; # b\n domain-name x; }", "system { # b\n domain-name x; # a\n host-name r1; }",
+			EqualOptions{IncludeComments: true}, true},
+		{"no ordered sections", "firewall { filter f { term a { then accept; } term b { then discard; } } }",
+			"firewall { filter f { term b { then discard; } term a { then accept; } } }", EqualOptions{OrderedSections: [][]string{}}, true},
+		{"comments ordered", "system { # a\n host-name r1; # b\n domain-name x; }", "system { # b\n domain-name x; # a\n host-name r1; }",
+			EqualOptions{IncludeComments: true, Ordered: true}, false},
+	} {
+		a, err := Parse(test.name, test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(test.name, test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.EqualWithOptions(b, test.opts); got != test.equal {
+			t.Errorf("%s: got %v, expected %v", test.name, got, test.equal)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package jcfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Get returns the node found by descending from the root one path segment
+// at a time, e.g. t.Get("system", "syslog", "file messages").
+//
+// A leaf statement is addressed by its keyword. A section is addressed by
+// its keyword followed by its identifying values separated by spaces,
+// exactly as in the configuration ("file messages", `user "*"`). A
+// section may also be addressed by its keyword alone as long as no other
+// section in the same parent shares that keyword. A segment matching more
+// than one statement is ambiguous and, like a missing segment, makes Get
+// return false. An empty path returns the root section.
+func (t *Tree) Get(path ...string) (Node, bool) {
+	return t.get(path, equalString)
+}
+
+// GetFold is like Get but compares path segments with the keywords and
+// values of statements case-insensitively, as by strings.EqualFold, so
+// GetFold("System", "Syslog") finds "system syslog". Junos itself is case
+// sensitive so this is only for configurations whose case is not to be
+// trusted; a segment matching statements differing only in case is
+// ambiguous.
+func (t *Tree) GetFold(path ...string) (Node, bool) {
+	return t.get(path, strings.EqualFold)
+}
+
+// get returns the node at path comparing segments with eq.
+func (t *Tree) get(path []string, eq func(a, b string) bool) (Node, bool) {
+	var n Node = t.Root
+	for _, segment := range path {
+		s, ok := n.(*SectionNode)
+		if !ok {
+			return nil, false
+		}
+		matches := s.lookupFunc(segment, eq)
+		if len(matches) != 1 {
+			return nil, false
+		}
+		n = matches[0]
+	}
+	return n, true
+}
+
+// Exists reports whether there is a statement at path, whether a leaf or
+// a section. Path segments are addressed as with Get, except that the
+// last segment may match more than one statement, so Exists("system",
+// "syslog", "file") reports whether there are any "file" sections. An
+// empty path always exists.
+func (t *Tree) Exists(path ...string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	n, ok := t.Get(path[:len(path)-1]...)
+	if !ok {
+		return false
+	}
+	s, ok := n.(*SectionNode)
+	return ok && len(s.lookup(path[len(path)-1])) > 0
+}
+
+// Children returns the statements directly inside s in order. Comments
+// are not included. The slice is newly allocated so changing it does not
+// change s.
+func (s *SectionNode) Children() []Node {
+	return statements(s.Nodes)
+}
+
+// ChildByKeyword returns every statement directly inside s with the
+// keyword kw, such as all of the "file" sections of "syslog", in order.
+func (s *SectionNode) ChildByKeyword(kw string) []Node {
+	var children []Node
+	for _, n := range s.Nodes {
+		switch n := n.(type) {
+		case *ValueNode:
+			if n.Keyword == kw {
+				children = append(children, n)
+			}
+		case *SectionNode:
+			if n.Keyword == kw {
+				children = append(children, n)
+			}
+		}
+	}
+	return children
+}
+
+// Named returns the sections directly inside s keyed by their name, the
+// keyword followed by the identifying values as used in a path, such as
+// "file messages". A keyword may be repeated under different names, as
+// with the "file" sections of "syslog", but Junos does not allow two
+// sections with the same name. If there are any, the first of each is in
+// the map and the error names the duplicates.
+func (s *SectionNode) Named() (map[string]*SectionNode, error) {
+	named := make(map[string]*SectionNode)
+	var dups []string
+	for _, n := range s.Nodes {
+		c, ok := n.(*SectionNode)
+		if !ok {
+			continue
+		}
+		name := c.name()
+		if _, ok := named[name]; !ok {
+			named[name] = c
+		} else if !contains(dups, name) {
+			dups = append(dups, name)
+		}
+	}
+	if len(dups) == 0 {
+		return named, nil
+	}
+	where := ""
+	if s.Keyword != "" {
+		where = s.name() + ": "
+	}
+	for i, name := range dups {
+		dups[i] = strconv.Quote(name)
+	}
+	return named, fmt.Errorf("jcfg: %sduplicate section %s", where, strings.Join(dups, ", "))
+}
+
+// child returns the single statement in s addressed by segment.
+func (s *SectionNode) child(segment string) (Node, bool) {
+	if matches := s.lookup(segment); len(matches) == 1 {
+		return matches[0], true
+	}
+	return nil, false
+}
+
+// lookup returns the statements in s addressed by segment. More than one
+// match means the segment is ambiguous.
+func (s *SectionNode) lookup(segment string) []Node {
+	return s.lookupFunc(segment, equalString)
+}
+
+// lookupFunc is like lookup but compares segment with eq.
+func (s *SectionNode) lookupFunc(segment string, eq func(a, b string) bool) []Node {
+	var exact, byKeyword []Node
+	for _, n := range s.Nodes {
+		switch n := n.(type) {
+		case *ValueNode:
+			if eq(n.Keyword, segment) {
+				exact = append(exact, n)
+			}
+		case *SectionNode:
+			if eq(n.name(), segment) {
+				exact = append(exact, n)
+			} else if eq(n.Keyword, segment) {
+				byKeyword = append(byKeyword, n)
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return byKeyword
+}
+
+// GetString returns the single value of the leaf statement at path with
+// any quoting removed as by Unquote. It returns false if there is no such
+// leaf or it does not have exactly one value.
+func (t *Tree) GetString(path ...string) (string, bool) {
+	n, ok := t.Get(path...)
+	if !ok {
+		return "", false
+	}
+	v, ok := n.(*ValueNode)
+	if !ok || len(v.Values) != 1 {
+		return "", false
+	}
+	return Unquote(v.Values[0]), true
+}
+
+// GetInt returns the single value of the leaf statement at path as a
+// decimal integer, as ValueNode.Int does. It returns false if there is no
+// such leaf or Int would, so a quoted value such as "1500" is not an
+// integer.
+func (t *Tree) GetInt(path ...string) (int, bool) {
+	n, ok := t.Get(path...)
+	if !ok {
+		return 0, false
+	}
+	v, ok := n.(*ValueNode)
+	if !ok {
+		return 0, false
+	}
+	i, ok := v.Int()
+	if !ok || int64(int(i)) != i {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// GetBool reports whether the flag at path, such as "disable;", is
+// present. Junos has no boolean values: an option is on when its
+// statement is present, so a section such as "ssh { ... }" counts as
+// well. A leaf statement is a flag as ValueNode.Bool reports, so one
+// with values or an empty list returns false.
+func (t *Tree) GetBool(path ...string) bool {
+	n, ok := t.Get(path...)
+	if !ok {
+		return false
+	}
+	if v, ok := n.(*ValueNode); ok {
+		return v.Bool()
+	}
+	return n.Type() == NodeSection && len(path) > 0
+}
+
+// equalString reports whether a and b are the same string.
+func equalString(a, b string) bool {
+	return a == b
+}
@@ -0,0 +1,362 @@
+package jcfg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type getTest struct {
+	name  string
+	path  []string
+	ok    bool
+	found string // String() of the node found
+}
+
+var getTests = []getTest{
+	{"root", nil, true, factoryConfig},
+	{"section", []string{"system", "syslog", "file messages"}, true,
+		"file messages {\n    any notice;\n    authorization info;\n}\n"},
+	{"leaf", []string{"system", "syslog", "file messages", "authorization"}, true, "authorization info;"},
+	{"quoted identifier", []string{"system", "syslog", `user "*"`, "any"}, true, "any emergency;"},
+	{"unique keyword", []string{"system", "syslog", "user", "any"}, true, "any emergency;"},
+	{"ambiguous keyword", []string{"system", "syslog", "file"}, false, ""},
+	{"missing section", []string{"system", "services"}, false, ""},
+	{"missing leaf", []string{"system", "syslog", "file messages", "change-log"}, false, ""},
+	{"below leaf", []string{"system", "syslog", "file messages", "any", "notice"}, false, ""},
+	{"leaf value", []string{"system", "syslog", "file messages", "any notice"}, false, ""},
+}
+
+func TestGet(t *testing.T) {
+	tree, err := Parse("get", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range getTests {
+		n, ok := tree.Get(test.path...)
+		if ok != test.ok {
+			t.Errorf("%s: got ok %v, expected %v", test.name, ok, test.ok)
+			continue
+		}
+		if !ok {
+			if n != nil {
+				t.Errorf("%s: expected nil node on a miss, got %v", test.name, n)
+			}
+			continue
+		}
+		if found := n.String(); found != test.found {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, found, test.found)
+		}
+	}
+}
+
+const typedConfig = `system {
+    host-name "r1";
+    commit-delay 30;
+    time-zone UTC;
+    no-redirects;
+    mtu "1500";
+    members [ ];
+    services {
+        ssh;
+    }
+}
+`
+
+func TestGetTyped(t *testing.T) {
+	tree, err := Parse("typed", typedConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stringTests := []struct {
+		path     []string
+		expected string
+		ok       bool
+	}{
+		{[]string{"system", "host-name"}, "r1", true},
+		{[]string{"system", "commit-delay"}, "30", true},
+		{[]string{"system", "no-redirects"}, "", false},
+		{[]string{"system", "services"}, "", false},
+		{[]string{"system", "domain-name"}, "", false},
+	}
+	for _, test := range stringTests {
+		got, ok := tree.GetString(test.path...)
+		if got != test.expected || ok != test.ok {
+			t.Errorf("GetString(%q): got %q, %v expected %q, %v", test.path, got, ok, test.expected, test.ok)
+		}
+	}
+
+	intTests := []struct {
+		path     []string
+		expected int
+		ok       bool
+	}{
+		{[]string{"system", "commit-delay"}, 30, true},
+		{[]string{"system", "time-zone"}, 0, false},
+		{[]string{"system", "no-redirects"}, 0, false},
+		{[]string{"system", "domain-name"}, 0, false},
+		{[]string{"system", "mtu"}, 0, false},
+	}
+	for _, test := range intTests {
+		got, ok := tree.GetInt(test.path...)
+		if got != test.expected || ok != test.ok {
+			t.Errorf("GetInt(%q): got %d, %v expected %d, %v", test.path, got, ok, test.expected, test.ok)
+		}
+	}
+
+	boolTests := []struct {
+		path     []string
+		expected bool
+	}{
+		{[]string{"system", "no-redirects"}, true},
+		{[]string{"system", "services", "ssh"}, true},
+		{[]string{"system", "services"}, true},
+		{[]string{"system", "host-name"}, false},
+		{[]string{"system", "services", "telnet"}, false},
+		{[]string{"system", "members"}, false},
+		{nil, false},
+	}
+	for _, test := range boolTests {
+		if got := tree.GetBool(test.path...); got != test.expected {
+			t.Errorf("GetBool(%q): got %v expected %v", test.path, got, test.expected)
+		}
+	}
+}
+
+func TestExists(t *testing.T) {
+	tree, err := Parse("exists", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range getTests {
+		ok := test.ok || test.name == "ambiguous keyword"
+		if got := tree.Exists(test.path...); got != ok {
+			t.Errorf("%s: got %v, expected %v", test.name, got, ok)
+		}
+	}
+}
+
+func TestGetFold(t *testing.T) {
+	tree, err := Parse("fold", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		path     []string
+		expected []string
+	}{
+		{[]string{"System", "Syslog"}, []string{"system", "syslog"}},
+		{[]string{"SYSTEM", "syslog", "File Messages", "ANY"}, []string{"system", "syslog", "file messages", "any"}},
+		{[]string{"system", "Syslog", "USER", "any"}, []string{"system", "syslog", "user", "any"}},
+		{[]string{"System", "Services"}, nil},
+		{[]string{"system", "syslog", "FILE"}, nil},
+	} {
+		n, ok := tree.GetFold(test.path...)
+		if ok != (test.expected != nil) {
+			t.Errorf("%q: got ok %v, expected %v", test.path, ok, !ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if expected, _ := tree.Get(test.expected...); n != expected {
+			t.Errorf("%q: got\n\t%v\nexpected\n\t%v", test.path, n, expected)
+		}
+	}
+	if _, ok := tree.Get("System", "Syslog"); ok {
+		t.Errorf("Get matched a path differing in case")
+	}
+}
+
+func TestGetQuotedName(t *testing.T) {
+	const input = `system {
+    login {
+        user "john doe" {
+            class super-user;
+        }
+        user jane {
+            class read-only;
+        }
+    }
+}
+policy-options {
+    policy-statement "my policy" {
+        then accept;
+    }
+}
+`
+	tree, err := Parse("quoted", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, input)
+	}
+	for _, test := range []struct {
+		path  []string
+		found string
+	}{
+		{[]string{"system", "login", `user "john doe"`, "class"}, "class super-user;"},
+		{[]string{"system", "login", "user jane", "class"}, "class read-only;"},
+		{[]string{"policy-options", `policy-statement "my policy"`, "then"}, "then accept;"},
+		{[]string{"policy-options", "policy-statement", "then"}, "then accept;"},
+	} {
+		n, ok := tree.Get(test.path...)
+		if !ok {
+			t.Errorf("%q: not found", test.path)
+			continue
+		}
+		if found := n.String(); found != test.found {
+			t.Errorf("%q: got %s, expected %s", test.path, found, test.found)
+		}
+	}
+
+	if err := tree.Set([]string{"system", "login", `user "john doe"`, "uid"}, "2000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"system", "login", `user "new user"`, "class"}, "operator"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tree.GetString("system", "login", `user "john doe"`, "uid"); got != "2000" {
+		t.Errorf("got uid %q, expected 2000", got)
+	}
+	n, ok := tree.Get("system", "login", `user "new user"`)
+	if !ok {
+		t.Fatal(`user "new user" not found`)
+	}
+	if s := n.(*SectionNode); s.Keyword != "user" || !reflect.DeepEqual(s.Values, []string{`"new user"`}) {
+		t.Errorf("got keyword %q values %q, expected user with a single quoted value", s.Keyword, s.Values)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	tree, err := Parse("children", "# comment\n"+factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if children := tree.Root.Children(); len(children) != 1 {
+		t.Errorf("got %d top-level statements, expected 1", len(children))
+	}
+	n, _ := tree.Get("system", "syslog")
+	syslog := n.(*SectionNode)
+
+	var names []string
+	for _, c := range syslog.Children() {
+		names = append(names, c.(*SectionNode).name())
+	}
+	expected := []string{"file messages", "file interactive-commands", `user "*"`}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", names, expected)
+	}
+
+	if files := syslog.ChildByKeyword("file"); len(files) != 2 {
+		t.Errorf("got %d file sections, expected 2", len(files))
+	}
+	if users := syslog.ChildByKeyword("user"); len(users) != 1 {
+		t.Errorf("got %d user sections, expected 1", len(users))
+	}
+	if none := syslog.ChildByKeyword("messages"); none != nil {
+		t.Errorf("got %v matching an identifier, expected none", none)
+	}
+
+	// Changing the returned slice leaves the section alone.
+	children := syslog.Children()
+	children[0] = nil
+	if syslog.Nodes[0] == nil {
+		t.Error("Children shares its slice with the section")
+	}
+}
+
+func TestNamed(t *testing.T) {
+	tree, err := Parse("named", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := tree.Get("system", "syslog")
+	named, err := n.(*SectionNode).Named()
+	if err != nil {
+		t.Fatalf("repeated keyword: unexpected error: %v", err)
+	}
+	var names []string
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"file interactive-commands", "file messages", `user "*"`}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", names, expected)
+	}
+	if named["file messages"] != tree.Root.Nodes[0].(*SectionNode).Nodes[0].(*SectionNode).Nodes[0] {
+		t.Errorf("file messages is not the section in the tree")
+	}
+
+	tree, err = Parse("named", "syslog { file messages { any notice; } file messages { any any; } user \"*\" { any emergency; } user \"*\" { } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err = tree.Root.Nodes[0].(*SectionNode).Named()
+	const expectedErr = `jcfg: syslog: duplicate section "file messages", "user \"*\""`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("got error\n\t%v\nexpected\n\t%v", err, expectedErr)
+	}
+	if s := named["file messages"]; s == nil || s.Nodes[0].(*ValueNode).Values[0] != "notice" {
+		t.Errorf("got %v for file messages, expected the first section", s)
+	}
+}
+
+func TestMultiTokenSection(t *testing.T) {
+	const input = `protocols {
+    interface ge-0/0/0 unit 0 {
+        metric 10;
+    }
+    interface ge-0/0/0 unit 1 {
+        metric 20;
+    }
+}
+`
+	tree, err := Parse("multi", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok := tree.Get("protocols", "interface ge-0/0/0 unit 1")
+	if !ok {
+		t.Fatal("section not found")
+	}
+	s := n.(*SectionNode)
+	if expected := []string{"ge-0/0/0", "unit", "1"}; s.Keyword != "interface" || !reflect.DeepEqual(s.Values, expected) {
+		t.Errorf("got %q %q, expected %q %q", s.Keyword, s.Values, "interface", expected)
+	}
+	if got, _ := tree.GetInt("protocols", "interface ge-0/0/0 unit 1", "metric"); got != 20 {
+		t.Errorf("got metric %d, expected 20", got)
+	}
+	if _, ok := tree.Get("protocols", "interface", "metric"); ok {
+		t.Errorf("ambiguous keyword matched a section")
+	}
+
+	if err := tree.Set([]string{"protocols", "interface ge-0/0/0 unit 0", "metric"}, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"protocols", "interface ge-0/0/1 unit 0", "passive"}); err != nil {
+		t.Fatal(err)
+	}
+	const expected = `protocols {
+    interface ge-0/0/0 unit 0 {
+        metric 5;
+    }
+    interface ge-0/0/0 unit 1 {
+        metric 20;
+    }
+    interface ge-0/0/1 unit 0 {
+        passive;
+    }
+}
+`
+	if result := tree.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	n, _ = tree.Get("protocols", "interface ge-0/0/1 unit 0")
+	if s := n.(*SectionNode); !reflect.DeepEqual(s.Values, []string{"ge-0/0/1", "unit", "0"}) {
+		t.Errorf("got values %q for the new section", s.Values)
+	}
+}
@@ -0,0 +1,236 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ApplyGroups returns a copy of the tree with configuration groups
+// expanded the way Junos does when committing it.
+//
+// Each child of the top-level "groups" section is a group holding
+// configuration laid out from the top of the hierarchy. An
+// "apply-groups" statement, such as "apply-groups COMMON;" or
+// "apply-groups [ A B ];", makes the section it is in, and every section
+// below it, inherit the statements of the named groups at the same path.
+// A statement is only inherited if the section does not already have a
+// statement with the same keyword, or section with the same name, so
+// configuration outside of groups always wins, and the groups named first
+// win over those named after them and over those applied higher up.
+// "apply-groups-except" stops the named groups from being inherited by
+// its section and those below it.
+//
+// A section of a group whose name, or one of whose identifying values, is
+// a wildcard in angle brackets, quoted or not, such as <ge-*> or unit <*>,
+// applies to every section it matches. In a wildcard "*" matches any
+// characters and "?" any single character. Statements from a section
+// matched by name are inherited before those from wildcard sections.
+//
+// The result has the "groups" section and all apply-groups and
+// apply-groups-except statements removed. Naming a group that does not
+// exist is an error.
+func (t *Tree) ApplyGroups() (*Tree, error) {
+	c := t.Clone()
+	groups := make(map[string]*SectionNode)
+	for _, n := range c.Root.Nodes {
+		s, ok := n.(*SectionNode)
+		if !ok || s.Keyword != "groups" || len(s.Values) != 0 {
+			continue
+		}
+		for _, n := range s.Nodes {
+			if g, ok := n.(*SectionNode); ok && len(g.Values) == 0 {
+				groups[g.Keyword] = g
+			}
+		}
+		c.Root.remove(s)
+		break
+	}
+	if err := applyGroups(c.Root, nil, nil, groups); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// applyGroups expands groups into s, which is at path and inherits the
+// groups named in inherited, and then into the sections below it.
+func applyGroups(s *SectionNode, path, inherited []string, groups map[string]*SectionNode) error {
+	var apply, except []string
+	for _, n := range statements(s.Nodes) {
+		v, ok := n.(*ValueNode)
+		if !ok {
+			continue
+		}
+		switch v.Keyword {
+		case "apply-groups":
+			apply = append(apply, v.Unquoted()...)
+		case "apply-groups-except":
+			except = append(except, v.Unquoted()...)
+		default:
+			continue
+		}
+		s.remove(v)
+	}
+
+	var active []string
+	for _, name := range append(apply, inherited...) {
+		if !contains(except, name) && !contains(active, name) {
+			active = append(active, name)
+		}
+	}
+	for _, name := range active {
+		g, ok := groups[name]
+		if !ok {
+			return fmt.Errorf("jcfg: %s: apply-groups: group %q not found", strings.Join(appendPath(path, "apply-groups"), " "), name)
+		}
+		for _, gs := range groupSections(g, path) {
+			inherit(s, gs)
+		}
+	}
+
+	for _, n := range s.Nodes {
+		if c, ok := n.(*SectionNode); ok {
+			if err := applyGroups(c, appendPath(path, c.name()), active, groups); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandGroups is the same as ApplyGroups. It is named for the effective
+// configuration it returns rather than for the apply-groups statements it
+// acts on, and is kept so that callers can use whichever name reads
+// better alongside the rest of their code.
+func (t *Tree) ExpandGroups() (*Tree, error) {
+	return t.ApplyGroups()
+}
+
+// groupSections returns the sections of the group g at path, those
+// matching by name before those matching by wildcard, or nil if the group
+// has nothing there.
+func groupSections(g *SectionNode, path []string) []*SectionNode {
+	sections := []*SectionNode{g}
+	for _, name := range path {
+		var next []*SectionNode
+		for _, s := range sections {
+			if c, ok := s.mergeMatch(name).(*SectionNode); ok {
+				next = append(next, c)
+			}
+		}
+		for _, s := range sections {
+			for _, n := range s.Nodes {
+				if c, ok := n.(*SectionNode); ok && c.name() != name && matchGroupName(c, name) {
+					next = append(next, c)
+				}
+			}
+		}
+		sections = next
+	}
+	return sections
+}
+
+// matchGroupName reports whether the name of the group section g, one of
+// whose keyword and values is a wildcard, matches the section name.
+func matchGroupName(g *SectionNode, name string) bool {
+	fields := strings.Split(name, " ")
+	if len(fields) != len(g.Values)+1 {
+		return false
+	}
+	wildcard := false
+	for i, f := range append([]string{g.Keyword}, g.Values...) {
+		switch {
+		case isWildcard(f):
+			if !matchWildcard(strings.Trim(Unquote(f), "<>"), Unquote(fields[i])) {
+				return false
+			}
+			wildcard = true
+		case f != fields[i]:
+			return false
+		}
+	}
+	return wildcard
+}
+
+// isWildcard reports whether the keyword or value s is a wildcard such as
+// "<ge-*>".
+func isWildcard(s string) bool {
+	s = Unquote(s)
+	return len(s) >= 2 && s[0] == '<' && s[len(s)-1] == '>'
+}
+
+// matchWildcard reports whether name matches pattern, in which "*" matches
+// any run of characters and "?" any single character.
+func matchWildcard(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(name); i >= 0; i-- {
+				if matchWildcard(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if name == "" {
+				return false
+			}
+			_, w := utf8.DecodeRuneInString(name)
+			name = name[w:]
+		default:
+			if name == "" || name[0] != pattern[0] {
+				return false
+			}
+			name = name[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return name == ""
+}
+
+// inherit adds copies of the statements of the group section g that s
+// does not have to the end of s. Sections both have are merged when the
+// sections below s are expanded.
+func inherit(s, g *SectionNode) {
+	have := make(map[string]bool)
+	for _, n := range statements(s.Nodes) {
+		have[mergeKey(n)] = true
+	}
+	for _, n := range statements(g.Nodes) {
+		if have[mergeKey(n)] {
+			continue
+		}
+		if gs, ok := n.(*SectionNode); ok && isWildcardSection(gs) {
+			continue
+		}
+		c := copyNode(s.tr, n)
+		if cs, ok := c.(*SectionNode); ok {
+			dropWildcards(cs)
+		}
+		s.append(c)
+	}
+}
+
+// isWildcardSection reports whether the name of the group section s has a
+// wildcard in it.
+func isWildcardSection(s *SectionNode) bool {
+	return strings.ContainsAny(s.name(), "<>")
+}
+
+// dropWildcards removes the wildcard sections at every depth below s, a
+// copy of part of a group. A wildcard only applies to the sections it
+// matches, and a copied section has none for it to match.
+func dropWildcards(s *SectionNode) {
+	nodes := s.Nodes[:0]
+	for _, n := range s.Nodes {
+		if c, ok := n.(*SectionNode); ok {
+			if isWildcardSection(c) {
+				continue
+			}
+			dropWildcards(c)
+		}
+		nodes = append(nodes, n)
+	}
+	clear(s.Nodes[len(nodes):])
+	s.Nodes = nodes
+}
@@ -0,0 +1,287 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyGroups(t *testing.T) {
+	const input = `groups {
+    LOGGING {
+        system {
+            host-name default;
+            syslog {
+                file messages {
+                    any notice;
+                }
+            }
+        }
+        snmp {
+            community public {
+                authorization read-only;
+            }
+            location default;
+        }
+    }
+}
+system {
+    apply-groups LOGGING;
+    host-name r1;
+}
+snmp {
+    apply-groups LOGGING;
+    location lab;
+}
+`
+	const expected = `system {
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+}
+snmp {
+    location lab;
+    community public {
+        authorization read-only;
+    }
+}
+`
+	tree, err := Parse("groups", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := tree.ApplyGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := applied.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("original tree modified:\n%s", result)
+	}
+}
+
+func TestApplyGroupsPriority(t *testing.T) {
+	const input = `groups {
+    A {
+        interfaces {
+            ge-0/0/0 {
+                mtu 1500;
+                description from-a;
+            }
+            ge-0/0/1 {
+                mtu 1500;
+            }
+            "<xe-*>" {
+                mtu 9192;
+            }
+        }
+    }
+    B {
+        interfaces {
+            ge-0/0/0 {
+                mtu 9192;
+                speed 1g;
+            }
+        }
+    }
+}
+apply-groups [ A B ];
+interfaces {
+    ge-0/0/0 {
+        description local;
+    }
+    ge-0/0/1 {
+        apply-groups-except A;
+    }
+    xe-0/0/0;
+}
+`
+	const expected = `interfaces {
+    ge-0/0/0 {
+        description local;
+        mtu 1500;
+        speed 1g;
+    }
+    ge-0/0/1 {
+    }
+    xe-0/0/0;
+}
+`
+	tree, err := Parse("priority", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := tree.ApplyGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := applied.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+}
+
+func TestApplyGroupsMissing(t *testing.T) {
+	tree, err := Parse("missing", "system { apply-groups NOPE; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.ApplyGroups(); err == nil {
+		t.Error("expected an error for a missing group")
+	}
+}
+
+func TestApplyGroupsNestedWildcard(t *testing.T) {
+	tree, err := Parse("nested", `groups {
+    G {
+        interfaces {
+            <ge-*> {
+                mtu 9000;
+            }
+            lo0 {
+                unit <*> {
+                    family inet;
+                }
+                description loopback;
+            }
+        }
+    }
+}
+apply-groups G;
+system {
+    host-name r1;
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `system {
+    host-name r1;
+}
+interfaces {
+    lo0 {
+        description loopback;
+    }
+}
+`
+	applied, err := tree.ApplyGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := applied.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+}
+
+func TestApplyGroupsWildcard(t *testing.T) {
+	const input = `groups {
+    MTU {
+        interfaces {
+            "<ge-*>" {
+                mtu 9192;
+                unit "<*>" {
+                    family inet;
+                }
+            }
+            lo0 {
+                description loopback;
+            }
+        }
+    }
+}
+interfaces {
+    ge-0/0/0 {
+        apply-groups MTU;
+        unit 0;
+        unit 1 {
+            description one;
+        }
+    }
+    ge-0/0/1 {
+        apply-groups MTU;
+        mtu 1500;
+    }
+    xe-0/0/0 {
+        apply-groups MTU;
+    }
+    lo0 {
+        apply-groups MTU;
+    }
+}
+`
+	const expected = `interfaces {
+    ge-0/0/0 {
+        unit 0;
+        unit 1 {
+            description one;
+            family inet;
+        }
+        mtu 9192;
+    }
+    ge-0/0/1 {
+        mtu 1500;
+    }
+    xe-0/0/0 {
+    }
+    lo0 {
+        description loopback;
+    }
+}
+`
+	tree, err := Parse("expand", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := tree.ApplyGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := expanded.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+
+	// Junos prints wildcards unquoted.
+	unquoted := strings.NewReplacer(`"<ge-*>"`, "<ge-*>", `"<*>"`, "<*>").Replace(input)
+	tree, err = Parse("expand", unquoted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err = tree.ExpandGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := expanded.String(); result != expected {
+		t.Errorf("unquoted wildcards: got\n%s\nexpected\n%s", result, expected)
+	}
+
+	tree, err = Parse("expand", "groups { A { system { ports; } } } system { apply-groups [ A B ]; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.ExpandGroups(); err == nil {
+		t.Error("expected an error for an unresolvable group")
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	for _, test := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*", "ge-0/0/0", true},
+		{"ge-*", "ge-0/0/0", true},
+		{"ge-*", "xe-0/0/0", false},
+		{"ge-?/0/0", "ge-1/0/0", true},
+		{"ge-?/0/0", "ge-10/0/0", false},
+		{"*/0/*", "ge-1/0/3", true},
+		{"", "", true},
+		{"", "a", false},
+	} {
+		if match := matchWildcard(test.pattern, test.name); match != test.match {
+			t.Errorf("%q %q: got %v, expected %v", test.pattern, test.name, match, test.match)
+		}
+	}
+}
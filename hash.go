@@ -0,0 +1,83 @@
+package jcfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sort"
+)
+
+// Hash returns a SHA-256 digest of the statements in the tree. Comments,
+// whitespace, the order of the statements within each section, whether
+// values are written as a list and the quoting of values that do not
+// need it are not part of the digest, so configurations that differ only
+// in formatting hash identically while any change to a keyword, value or
+// modifier gives a different hash. The order of the sections matched by
+// DefaultOrderedSections, such as firewall filter terms, is part of the
+// digest. Trees that are Equal always have the same hash.
+func (t *Tree) Hash() [32]byte {
+	return hashSection(nil, "root", "", "", nil, t.Root.Nodes)
+}
+
+// hashNode returns the digest of the statement n. Every string is
+// prefixed with its length so that no two different statements write the
+// same bytes. path is the path of the section n is in.
+func hashNode(path []string, n Node) [32]byte {
+	switch n := n.(type) {
+	case *ValueNode:
+		h := sha256.New()
+		hashStrings(h, "leaf", n.Modifier, n.Keyword)
+		hashStrings(h, unquoteValues(n.Values)...)
+		var sum [32]byte
+		h.Sum(sum[:0])
+		return sum
+	case *SectionNode:
+		return hashSection(appendPath(path, n.name()), "section", n.Modifier, n.Keyword, unquoteValues(n.Values), n.Nodes)
+	}
+	return [32]byte{}
+}
+
+// hashSection returns the digest of the section at path from the digests
+// of the statements in nodes, sorted so that their order does not matter,
+// followed by those of its ordered sections in their order. The keyword
+// and each of the values naming the section are hashed separately, as for
+// a leaf, so that "a b" and a b do not collide.
+func hashSection(path []string, kind, modifier, keyword string, values []string, nodes []Node) [32]byte {
+	stmts := statements(nodes)
+	sums := make([][32]byte, len(stmts))
+	var ordered [][32]byte
+	for i, n := range stmts {
+		sums[i] = hashNode(path, n)
+		if s, ok := n.(*SectionNode); ok && orderedSection(nil, appendPath(path, s.name())) {
+			ordered = append(ordered, sums[i])
+		}
+	}
+	sort.Slice(sums, func(i, j int) bool {
+		return bytes.Compare(sums[i][:], sums[j][:]) < 0
+	})
+	h := sha256.New()
+	hashStrings(h, kind, modifier, keyword)
+	hashStrings(h, values...)
+	for _, s := range sums {
+		h.Write(s[:])
+	}
+	if len(ordered) > 0 {
+		hashStrings(h, "ordered")
+		for _, s := range ordered {
+			h.Write(s[:])
+		}
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func hashStrings(h hash.Hash, s ...string) {
+	buf := binary.AppendUvarint(nil, uint64(len(s)))
+	for _, v := range s {
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		buf = append(buf, v...)
+	}
+	h.Write(buf)
+}
@@ -0,0 +1,57 @@
+package jcfg
+
+import (
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	base, err := Parse("base", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const reformatted = `# archived copy
+system { syslog {
+  file messages { any notice; authorization "info"; }
+  /* interactive */
+  file interactive-commands { interactive-commands any; }
+  user "*" { any emergency; } } }`
+	for _, test := range []struct {
+		name  string
+		input string
+		equal bool
+	}{
+		{"reformatted", reformatted, true},
+		{"changed value", `system { syslog { file messages { any notice; authorization any; } file interactive-commands { interactive-commands any; } user "*" { any emergency; } } }`, false},
+		{"reordered", `system { syslog { file interactive-commands { interactive-commands any; } file messages { authorization info; any notice; } user "*" { any emergency; } } }`, true},
+		{"modifier", `inactive: system { syslog { file messages { any notice; authorization info; } file interactive-commands { interactive-commands any; } user "*" { any emergency; } } }`, false},
+		{"leaf to section", `system { syslog { file messages { any notice; authorization info; } file interactive-commands { interactive-commands any; } user "*" { any { emergency; } } } }`, false},
+	} {
+		tree, err := Parse(test.name, test.input)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if equal := tree.Hash() == base.Hash(); equal != test.equal {
+			t.Errorf("%s: got equal hash %v, expected %v", test.name, equal, test.equal)
+		}
+	}
+}
+
+func TestHashSectionValues(t *testing.T) {
+	for _, test := range [][2]string{
+		{`file "a b" { any notice; }`, `file a b { any notice; }`},
+		{`file "a b" { any notice; }`, `"file a" b { any notice; }`},
+		{`file a { }`, `file "" a { }`},
+	} {
+		a, err := Parse("a", test[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse("b", test[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Hash() == b.Hash() {
+			t.Errorf("%s and %s hash the same", test[0], test[1])
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package jcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarshalJSON implements json.Marshaler. Sections become nested objects
+// and leaf statements become keyed values: a flag such as "disable;" is
+// true, a single value is a string and multiple values or a list are an
+// array of strings.
+//
+// As in the JSON written by Junos, a section with identifying values
+// (e.g. "messages" in "file messages { ... }") is an element of an array
+// holding every section with its keyword, and the values are stored
+// under a "name" key as the first member of its object. A section that
+// also holds a statement with the keyword "name" instead stores them as
+// "@": {"name": ...} so that the two cannot be confused.
+//
+// Keys are written in the order they first appear in the configuration.
+// When a keyword is repeated within a section, as with multiple "file"
+// stanzas under syslog, all statements with that keyword are collected
+// into an array at the position of the first one. Comments are omitted.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	writeJSONSection(&b, t.Root)
+	return b.Bytes(), nil
+}
+
+func writeJSONSection(b *bytes.Buffer, s *SectionNode) {
+	b.WriteByte('{')
+	first := true
+	if len(s.Values) > 0 {
+		name := strings.Join(unquoteValues(s.Values), " ")
+		if len(s.ChildByKeyword("name")) > 0 {
+			b.WriteString(`"@":{"name":`)
+			writeJSONString(b, name)
+			b.WriteByte('}')
+		} else {
+			writeJSONString(b, "name")
+			b.WriteByte(':')
+			writeJSONString(b, name)
+		}
+		first = false
+	}
+	for _, group := range groupByKeyword(statements(s.Nodes)) {
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		writeJSONString(b, keyword(group[0]))
+		b.WriteByte(':')
+		if s, ok := group[0].(*SectionNode); len(group) == 1 && (!ok || len(s.Values) == 0) {
+			writeJSONNode(b, group[0])
+			continue
+		}
+		b.WriteByte('[')
+		for i, n := range group {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSONNode(b, n)
+		}
+		b.WriteByte(']')
+	}
+	b.WriteByte('}')
+}
+
+func writeJSONNode(b *bytes.Buffer, n Node) {
+	switch n := n.(type) {
+	case *SectionNode:
+		writeJSONSection(b, n)
+	case *ValueNode:
+		switch {
+		case n.List || len(n.Values) > 1:
+			b.WriteByte('[')
+			for i, v := range n.Values {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				writeJSONString(b, Unquote(v))
+			}
+			b.WriteByte(']')
+		case len(n.Values) == 1:
+			writeJSONString(b, Unquote(n.Values[0]))
+		default:
+			b.WriteString("true")
+		}
+	}
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	// Marshaling a string never fails.
+	buf, _ := json.Marshal(s)
+	b.Write(buf)
+}
+
+// groupByKeyword groups statements by their keyword, keeping the groups in
+// the order each keyword first appears.
+func groupByKeyword(nodes []Node) [][]Node {
+	var groups [][]Node
+	index := make(map[string]int)
+	for _, n := range nodes {
+		kw := keyword(n)
+		i, ok := index[kw]
+		if !ok {
+			i = len(groups)
+			index[kw] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], n)
+	}
+	return groups
+}
+
+// keyword returns the keyword of a statement node.
+func keyword(n Node) string {
+	switch n := n.(type) {
+	case *ValueNode:
+		return n.Keyword
+	case *SectionNode:
+		return n.Keyword
+	}
+	return ""
+}
+
+// ParseJSON builds a Tree from configuration in the JSON format written
+// by Junos with "show configuration | display json", or by MarshalJSON.
+// The object keys are statement keywords, in order, and an outer
+// "configuration" object is unwrapped:
+//
+//   - an object is a section;
+//   - an array of objects is a section for each of them, with the value
+//     of its first "name" member as its identifying value, or a leaf
+//     statement for an object holding only a "name". Only the elements of
+//     an array are identified this way, so elsewhere "name" is an
+//     ordinary leaf statement;
+//   - a string or number is the value of a leaf statement, quoted as by
+//     Quote if needed, and an array of them is a list;
+//   - true, null and [null] are a flag, and false is omitted.
+//
+// Members starting with "@" hold metadata and are skipped, except that
+// "@": {"inactive": true} marks a statement inactive and "@": {"name": ...},
+// as written by MarshalJSON, identifies a section like a "name" member.
+func ParseJSON(data []byte) (*Tree, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	p := &jsonParser{d: d, t: New("json")}
+	if err := p.expect(json.Delim('{')); err != nil {
+		return nil, err
+	}
+	if err := p.members(p.t.Root, false); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("jcfg: json: unexpected data after the top-level object")
+	}
+	root := p.t.Root
+	if len(root.Nodes) == 1 {
+		if s, ok := root.Nodes[0].(*SectionNode); ok && s.Keyword == "configuration" && len(s.Values) == 0 {
+			root.Nodes = s.Nodes
+		}
+	}
+	return p.t, nil
+}
+
+// jsonParser builds a Tree from the tokens of a JSON document.
+type jsonParser struct {
+	d *json.Decoder
+	t *Tree
+}
+
+// token returns the next JSON token, turning the end of the input into
+// an error.
+func (p *jsonParser) token() (json.Token, error) {
+	tok, err := p.d.Token()
+	if err == io.EOF {
+		return nil, fmt.Errorf("jcfg: json: unexpected end of input")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jcfg: json: %v", err)
+	}
+	return tok, nil
+}
+
+func (p *jsonParser) expect(delim json.Delim) error {
+	tok, err := p.token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("jcfg: json: expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// members adds the statements for the members of an object, whose
+// opening brace has been read, to s. If named is set the object is an
+// element of an array and its first "name" member identifies s.
+func (p *jsonParser) members(s *SectionNode, named bool) error {
+	for p.d.More() {
+		tok, err := p.token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		switch {
+		case key == "@":
+			if err := p.attributes(s); err != nil {
+				return err
+			}
+		case strings.HasPrefix(key, "@"):
+			if err := p.skip(); err != nil {
+				return err
+			}
+		case key == "name" && named && len(s.Values) == 0:
+			tok, err := p.token()
+			if err != nil {
+				return err
+			}
+			name, ok := jsonScalar(tok)
+			if !ok {
+				return fmt.Errorf("jcfg: json: %s: name is not a string", s.Keyword)
+			}
+			s.Values = append(s.Values, Quote(name))
+		default:
+			if err := p.value(s, key); err != nil {
+				return err
+			}
+		}
+	}
+	return p.expect(json.Delim('}'))
+}
+
+// attributes reads the "@" object of s, applying "inactive" and "name".
+func (p *jsonParser) attributes(s *SectionNode) error {
+	if err := p.expect(json.Delim('{')); err != nil {
+		return err
+	}
+	for p.d.More() {
+		tok, err := p.token()
+		if err != nil {
+			return err
+		}
+		switch tok.(string) {
+		case "inactive":
+			if tok, err = p.token(); err != nil {
+				return err
+			}
+			if tok == true {
+				s.Modifier = "inactive"
+			}
+		case "name":
+			if tok, err = p.token(); err != nil {
+				return err
+			}
+			name, ok := jsonScalar(tok)
+			if !ok || len(s.Values) > 0 {
+				return fmt.Errorf("jcfg: json: %s: invalid name", s.Keyword)
+			}
+			s.Values = append(s.Values, Quote(name))
+		default:
+			if err := p.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.expect(json.Delim('}'))
+}
+
+// value adds the statements for the member key of an object to s.
+func (p *jsonParser) value(s *SectionNode, key string) error {
+	kw := key
+	if !validKeyword(kw) {
+		kw = Quote(kw)
+	}
+	tok, err := p.token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		c := p.t.newSection(NoPos, kw, nil)
+		s.append(c)
+		return p.members(c, false)
+	case json.Delim('['):
+		return p.array(s, kw)
+	case true, nil:
+		s.append(p.t.newValue(NoPos, kw, nil))
+		return nil
+	case false:
+		return nil
+	}
+	v, ok := jsonScalar(tok)
+	if !ok {
+		return fmt.Errorf("jcfg: json: %s: unexpected %v", key, tok)
+	}
+	s.append(p.t.newValue(NoPos, kw, []string{Quote(v)}))
+	return nil
+}
+
+// array adds the statements for an array, whose opening bracket has been
+// read, with the keyword kw to s.
+func (p *jsonParser) array(s *SectionNode, kw string) error {
+	var list *ValueNode
+	for p.d.More() {
+		tok, err := p.token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			c := p.t.newSection(NoPos, kw, nil)
+			if err := p.members(c, true); err != nil {
+				return err
+			}
+			if len(c.Nodes) == 0 && len(c.Values) > 0 {
+				v := p.t.newValue(NoPos, kw, c.Values)
+				v.Modifier = c.Modifier
+				s.append(v)
+				continue
+			}
+			s.append(c)
+		case nil:
+			s.append(p.t.newValue(NoPos, kw, nil))
+		default:
+			v, ok := jsonScalar(tok)
+			if !ok {
+				return fmt.Errorf("jcfg: json: %s: unexpected %v in array", kw, tok)
+			}
+			if list == nil {
+				list = p.t.newValue(NoPos, kw, nil)
+				list.List = true
+				s.append(list)
+			}
+			list.Values = append(list.Values, Quote(v))
+		}
+	}
+	return p.expect(json.Delim(']'))
+}
+
+// skip reads and discards the next value.
+func (p *jsonParser) skip() error {
+	depth := 0
+	for {
+		tok, err := p.token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// jsonScalar returns a string or number token as a string.
+func jsonScalar(tok json.Token) (string, bool) {
+	switch v := tok.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,169 @@
+package jcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+type jsonTest struct {
+	name   string
+	input  string
+	result string
+}
+
+var jsonTests = []jsonTest{
+	{"empty", "", `{}`},
+	{"flag", "disable;", `{"disable":true}`},
+	{"single value", "host-name r1;", `{"host-name":"r1"}`},
+	{"multiple values", "members a b;", `{"members":["a","b"]}`},
+	{"quoted value", `description "uplink to core";`, `{"description":"uplink to core"}`},
+	{"named section", "file messages { any notice; }", `{"file":[{"name":"messages","any":"notice"}]}`},
+	{"name leaf", "snmp { name r1; }", `{"snmp":{"name":"r1"}}`},
+	{"name leaf in named section", "user alice { name x; }", `{"user":[{"@":{"name":"alice"},"name":"x"}]}`},
+	{"repeated keys", "file a { any; } host-name r1; file b { any; }",
+		`{"file":[{"name":"a","any":true},{"name":"b","any":true}],"host-name":"r1"}`},
+	{"comments omitted", "# comment\nsystem { /* block */ ports; }", `{"system":{"ports":true}}`},
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, test := range jsonTests {
+		tree, err := Parse(test.name, test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(tree)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if string(b) != test.result {
+			t.Errorf("%s: got\n\t%s\nexpected\n\t%s", test.name, b, test.result)
+		}
+	}
+}
+
+func TestMarshalJSONGolden(t *testing.T) {
+	const golden = "testdata/junos-factory.json"
+
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.MarshalIndent(tree, "", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = append(b, '\n')
+
+	if *update {
+		if err := ioutil.WriteFile(golden, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Errorf("got\n%s\nexpected\n%s", b, expected)
+	}
+}
+
+var parseJSONTests = []jsonTest{
+	{"empty", `{}`, ""},
+	{"flags", `{"disable":true,"ports":[null],"no-redirects":null,"enabled":false}`, "disable;\nports;\nno-redirects;\n"},
+	{"values", `{"host-name":"r1","mtu":9192,"description":"uplink to core"}`,
+		"host-name r1;\nmtu 9192;\ndescription \"uplink to core\";\n"},
+	{"list", `{"vlan":{"members":["a","b c"]}}`, "vlan {\n    members [ a \"b c\" ];\n}\n"},
+	{"named sections", `{"file":[{"name":"a","any":true},{"name":"b"}]}`, "file a {\n    any;\n}\nfile b;\n"},
+	{"name leaf", `{"snmp":{"name":"r1","location":"lab"}}`, "snmp {\n    name r1;\n    location lab;\n}\n"},
+	{"name leaf in named section", `{"user":[{"name":"alice","name":"x"}]}`, "user alice {\n    name x;\n}\n"},
+	{"inactive", `{"interfaces":{"@":{"inactive":true},"ge-0/0/0":{"mtu":1500}}}`,
+		"inactive: interfaces {\n    ge-0/0/0 {\n        mtu 1500;\n    }\n}\n"},
+	{"metadata skipped", `{"system":{"@host-name":{"inactive":true},"host-name":"r1"}}`, "system {\n    host-name r1;\n}\n"},
+	{"quoted keyword", `{"commands":{"show version":[null]}}`, "commands {\n    \"show version\";\n}\n"},
+	{"configuration unwrapped", `{"configuration":{"version":"20.4R3"}}`, "version 20.4R3;\n"},
+}
+
+func TestParseJSON(t *testing.T) {
+	for _, test := range parseJSONTests {
+		tree, err := ParseJSON([]byte(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if result := tree.String(); result != test.result {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, result, test.result)
+		}
+	}
+}
+
+func TestParseJSONFile(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/junos-factory.junos.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("got\n%s\nexpected\n%s", result, factoryConfig)
+	}
+
+	// The output of MarshalJSON parses back to the same configuration.
+	b, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree, err = ParseJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, factoryConfig)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		"user alice {\n    name x;\n    class super-user;\n}\n",
+		"user alice {\n    class super-user;\n}\nuser bob {\n    name bob;\n}\n",
+		"snmp {\n    name r1;\n    location lab;\n}\n",
+	} {
+		tree, err := Parse("round trip", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseJSON(b)
+		if err != nil {
+			t.Errorf("%s: %v", b, err)
+			continue
+		}
+		if result := parsed.String(); result != input {
+			t.Errorf("%s: got\n%s\nexpected\n%s", b, result, input)
+		}
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`[]`,
+		`{"system":`,
+		`{"system":{}} {}`,
+		`{"file":[{"name":{}}]}`,
+		`{"members":[["a"]]}`,
+	} {
+		if _, err := ParseJSON([]byte(input)); err == nil {
+			t.Errorf("%q: expected error; got none", input)
+		}
+	}
+}
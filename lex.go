@@ -2,6 +2,9 @@ package jcfg
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -30,11 +33,13 @@ const (
 	tokenSectionStart                  // Start of a section '{'
 	tokenSectionEnd                    // End of a section '}'
 	tokenLineComment                   // Line comment starting with // until the end of a line (Not technically used in Junos)
-	tokenHashComment                   // Line comment starting with a # or ## until the end of a line
+	tokenHashComment                   // Line comment starting with a # until the end of a line
 	tokenBlockComment                  // Multiline capaible comment starting with /* and ending with */
 	tokenModifier                      // Modifier at the start of a statement (e.g 'deactivate:')
 	tokenListStart                     // Start of a list '['
 	tokenListEnd                       // End of a list ']'
+	tokenAnnotation                    // Annotation starting with exactly ## until the end of a line
+	tokenNumber                        // Value that is a number such as 1500, -1 or 1.5
 )
 
 const (
@@ -43,21 +48,87 @@ const (
 
 type stateFn func(*lexer) stateFn
 
+// lexer holds the state of the scanner. The state functions are run by
+// nextToken in the caller's goroutine only as far as is needed to produce
+// the next token.
 type lexer struct {
 	name   string
 	input  string
 	start  int
 	pos    int
 	width  int
-	tokens chan token
+	state  stateFn // next state function to run, nil once lexing is done
+	tokens []token // tokens emitted but not yet returned by nextToken
+	head   int     // index in tokens of the next one to return
+
+	// lines holds the offset of the start of each line of the input up to
+	// linesScanned, which lineStart extends as it is asked about later
+	// offsets.
+	lines        []int
+	linesScanned int
+
+	// Streaming input only. Data read from r is appended to buf and input
+	// is refreshed from it as it is needed.
+	r       io.Reader
+	buf     strings.Builder
+	readErr error
+}
+
+// readSize is the number of bytes read from a streaming input at a time.
+const readSize = 4096
+
+// fill ensures at least n bytes are available after pos, reading more of
+// a streaming input as needed. It reports whether n bytes are available.
+func (l *lexer) fill(n int) bool {
+	for len(l.input)-l.pos < n {
+		if l.r == nil || l.readErr != nil {
+			return false
+		}
+		var chunk [readSize]byte
+		m, err := l.r.Read(chunk[:])
+		l.buf.Write(chunk[:m])
+		if err != nil {
+			l.readErr = err
+		}
+		l.input = l.buf.String()
+	}
+	return true
+}
+
+// available reports whether the byte of the input at offset i is
+// available, reading more of a streaming input as needed.
+func (l *lexer) available(i int) bool {
+	return l.fill(i + 1 - l.pos)
+}
+
+// hasPrefix reports whether the input at the current position starts
+// with prefix.
+func (l *lexer) hasPrefix(prefix string) bool {
+	l.fill(len(prefix))
+	return strings.HasPrefix(l.input[l.pos:], prefix)
 }
 
 func (l *lexer) emit(t tokenType) {
-	l.tokens <- token{t, l.start, l.input[l.start:l.pos]}
+	l.send(token{t, l.start, l.input[l.start:l.pos]})
 	l.start = l.pos
 }
 
+// send queues t to be returned by nextToken.
+func (l *lexer) send(t token) {
+	l.tokens = append(l.tokens, t)
+}
+
+// stop tells the lexer that no more tokens will be read, releasing the
+// input and any tokens not yet returned.
+func (l *lexer) stop() {
+	l.state = nil
+	l.tokens = nil
+	l.head = 0
+	l.r = nil
+}
+
 func (l *lexer) next() rune {
+	l.fill(utf8.UTFMax)
 	if l.pos >= len(l.input) {
 		l.width = 0
 		return eof
@@ -91,69 +162,177 @@ func (l *lexer) skipSpace() {
 	l.ignore()
 }
 
+// atLineComment reports whether the input at the current position starts
+// a line comment.
+func (l *lexer) atLineComment() bool {
+	return l.hasPrefix(lineComment)
+}
+
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.tokens <- token{tokenError, l.start, fmt.Sprintf(format, args...)}
+	l.send(token{tokenError, l.start, fmt.Sprintf(format, args...)})
 	return nil
 }
 
+// contextWidth is how many bytes of input on either side of an invalid
+// character are shown in the error.
+const contextWidth = 20
+
+// context returns the input around pos on the same line, quoted for an
+// error message, such as "...interfaces ? ge-0...".
+func (l *lexer) context(pos int) string {
+	l.fill(contextWidth + utf8.UTFMax)
+	lineStart := strings.LastIndexAny(l.input[:pos], "\r\n") + 1
+	lineEnd := len(l.input)
+	if i := strings.IndexAny(l.input[pos:], "\r\n"); i >= 0 {
+		lineEnd = pos + i
+	}
+	start, end := pos-contextWidth, pos+1+contextWidth
+	if start < lineStart {
+		start = lineStart
+	}
+	if end > lineEnd {
+		end = lineEnd
+	}
+	for start < pos && !utf8.RuneStart(l.input[start]) {
+		start++
+	}
+	for end < lineEnd && end > pos+1 && !utf8.RuneStart(l.input[end]) {
+		end--
+	}
+	snippet := l.input[start:end]
+	if start > lineStart {
+		snippet = "..." + snippet
+	}
+	if end < lineEnd {
+		snippet += "..."
+	}
+	return strconv.Quote(snippet)
+}
+
+// text returns the input read so far.
+func (l *lexer) text() string {
+	return l.input
+}
+
+// lineStart returns the offset of the start of the line of the input
+// that pos, which must have been read, is on. A line starts after every
+// '\r' or '\n'. Each byte of the input is only looked at once however
+// many times lineStart is called.
+func (l *lexer) lineStart(pos int) int {
+	if l.lines == nil {
+		l.lines = []int{0}
+	}
+	for ; l.linesScanned < pos; l.linesScanned++ {
+		if isEndOfLine(rune(l.input[l.linesScanned])) {
+			l.lines = append(l.lines, l.linesScanned+1)
+		}
+	}
+	return l.lines[sort.SearchInts(l.lines, pos+1)-1]
+}
+
 // lineNumber reports which line we're on. Doing it this way
 // means we don't have to worry about peek double counting.
 func (l *lexer) lineNumber(pos int) int {
-	return 1 + strings.Count(l.input[:pos], "\n")
+	return lineNumber(l.input, pos)
+}
+
+// lineNumber returns the line of input, starting at 1, that the byte
+// offset pos is on. A "\r\n" pair counts as a single line ending.
+func lineNumber(input string, pos int) int {
+	s := input[:pos]
+	return 1 + strings.Count(s, "\n") + strings.Count(s, "\r") - strings.Count(s, "\r\n")
 }
 
-// columnNumber reports which column in the current line we're on.
-func (l *lexer) columnNumber(pos int) int {
-	n := strings.LastIndex(l.input[:pos], "\n")
-	if n == -1 {
-		n = 0
+// columnNumber returns the column in bytes, starting at 1, of the byte
+// offset pos within its line of input.
+func columnNumber(input string, pos int) int {
+	return pos - strings.LastIndexAny(input[:pos], "\r\n")
+}
+
+// runeColumn returns the column, starting at 1, of the byte offset pos
+// within its line of input counting runes. A tab advances to the column
+// after the next multiple of tabWidth if tabWidth is positive, and is a
+// single column otherwise.
+func runeColumn(input string, pos, tabWidth int) int {
+	col := 0
+	for _, r := range input[strings.LastIndexAny(input[:pos], "\r\n")+1 : pos] {
+		if r == '\t' && tabWidth > 0 {
+			col += tabWidth - col%tabWidth
+		} else {
+			col++
+		}
 	}
-	return int(pos) - n
+	return col + 1
 }
 
-// nextToken returns the next token from the input.
+// nextToken returns the next token from the input, running the state
+// functions until one is emitted. Once lexing has finished with tokenEOF
+// or tokenError it keeps returning tokenEOF.
 func (l *lexer) nextToken() token {
-	token := <-l.tokens
-	//	l.lastPos = token.pos
-	return token
+	for l.head == len(l.tokens) {
+		if l.state == nil {
+			return token{tokenEOF, l.pos, ""}
+		}
+		l.tokens = l.tokens[:0]
+		l.head = 0
+		l.state = l.state(l)
+	}
+	t := l.tokens[l.head]
+	l.head++
+	return t
 }
 
 func lex(name, input string) *lexer {
-	l := &lexer{
-		name:   name,
-		input:  input,
-		tokens: make(chan token),
+	return &lexer{
+		name:  name,
+		input: input,
+		state: lexInsideSection,
 	}
-	go l.run()
-	return l
 }
 
-func (l *lexer) run() {
-	for state := lexInsideSection; state != nil; {
-		state = state(l)
+// lexAll lexes all of input and returns its tokens, ending with tokenEOF
+// or tokenError. It suits callers that want every token at once.
+func lexAll(name, input string) []token {
+	l := lex(name, input)
+	for l.state != nil {
+		l.state = l.state(l)
+	}
+	return l.tokens
+}
+
+// lexReader is like lex but consumes the input from r as it is needed
+// rather than requiring it all up front.
+func lexReader(name string, r io.Reader) *lexer {
+	return &lexer{
+		name:  name,
+		r:     r,
+		state: lexInsideSection,
 	}
-	close(l.tokens)
 }
 
 const (
 	lineComment       = "//"
 	hashComment       = "#"
+	annotation        = "##"
 	leftBlockComment  = "/*"
 	rightBlockComment = "*/"
 )
 
 func lexInsideSection(l *lexer) stateFn {
 	for {
-		if strings.HasPrefix(l.input[l.pos:], lineComment) {
+		if l.atLineComment() {
 			return lexLineComment
 		}
 
-		if strings.HasPrefix(l.input[l.pos:], leftBlockComment) {
+		if l.hasPrefix(leftBlockComment) {
 			return lexBlockComment
 		}
 
 		switch r := l.next(); {
 		case r == eof:
+			if l.readErr != nil && l.readErr != io.EOF {
+				return l.errorf("%v", l.readErr)
+			}
 			l.emit(tokenEOF)
 			return nil
 		case r == '#':
@@ -161,27 +340,23 @@ func lexInsideSection(l *lexer) stateFn {
 			return lexHashComment
 		case r == '}':
 			l.emit(tokenSectionEnd)
-		case isAlphaNumeric(r):
+		case isAlphaNumeric(r) || r == '"' || r == '<':
 			l.backup()
 			return lexStatement
 		case unicode.IsSpace(r):
 			l.ignore()
 			continue
 		default:
-			l.errorf("Invalid statement: %s", string(r))
+			return l.errorf("invalid character %q: %s", r, l.context(l.pos-l.width))
 		}
 	}
-
-	// Reached EOF
-	l.emit(tokenEOF)
-	return nil
 }
 
+// lexStatement scans the start of a statement. Anything other than a
+// keyword, such as a modifier followed by ';', is left for lexInsideSection
+// so that the parser sees the modifier is not followed by a statement.
 func lexStatement(l *lexer) stateFn {
 	switch r := l.next(); {
-	case r == ';' || r == eof:
-		l.emit(tokenKeyword)
-		return lexEndStatement
 	case unicode.IsSpace(r):
 		for unicode.IsSpace(l.peek()) {
 			l.next()
@@ -189,16 +364,51 @@ func lexStatement(l *lexer) stateFn {
 		l.ignore()
 	case isAlphaNumeric(r):
 		return lexKeyword
+	case r == '"':
+		return lexQuotedKeyword
+	case r == '<':
+		return lexWildcardKeyword
+	default:
+		l.backup()
+		return lexInsideSection
 	}
 	return lexStatement
 }
 
+// lexQuotedKeyword scans a keyword written as a quoted string, as Junos
+// does for statements such as the commands of an event policy
+// ("show version";).
+func lexQuotedKeyword(l *lexer) stateFn {
+	if !l.scanQuote() {
+		return l.errorf("unterminated quoted string")
+	}
+	l.emit(tokenKeyword)
+	return lexValues
+}
+
+// lexWildcardKeyword scans a keyword that is a wildcard in angle brackets,
+// such as <ge-*> in a configuration group, which Junos writes unquoted.
+func lexWildcardKeyword(l *lexer) stateFn {
+	if !l.scanWildcard() {
+		return l.errorf("unterminated wildcard: %s", l.context(l.start))
+	}
+	l.emit(tokenKeyword)
+	return lexValues
+}
+
 func lexKeyword(l *lexer) stateFn {
-	for isAlphaNumeric(l.peek()) {
+	for r := l.peek(); isValueChar(r); r = l.peek() {
+		if r == ':' && l.endsModifier() {
+			break
+		}
 		l.next()
 	}
+	// A colon ending the leading word ends a modifier such as "inactive:".
+	// Colons within a keyword, as in the IPv6 prefix 2001:db8::/32, and
+	// later in the statement are part of the keyword or a value.
 	if l.peek() == ':' {
 		l.emit(tokenModifier)
+		l.next()
 		l.ignore()
 		return lexStatement
 	}
@@ -206,46 +416,67 @@ func lexKeyword(l *lexer) stateFn {
 	return lexValues
 }
 
+// endsModifier reports whether the ':' at the current position ends a
+// modifier. It must end a word with no other ':' in it and be followed by
+// something, such as a space or ';', that cannot continue the word.
+func (l *lexer) endsModifier() bool {
+	if strings.Contains(l.input[l.start:l.pos], ":") {
+		return false
+	}
+	l.fill(1 + utf8.UTFMax)
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos+1:])
+	return !isValueChar(r)
+}
+
 func lexValues(l *lexer) stateFn {
 	switch r := l.next(); {
 	case r == '"':
 		return lexQuote
+	case r == '<':
+		return lexWildcard
 	case r == '{':
 		return lexSectionStart
-	case r == ';' || r == '\n' || r == eof:
+	case r == '[':
+		l.emit(tokenListStart)
+	case r == ']':
+		l.emit(tokenListEnd)
+	case r == ';' || isEndOfLine(r) || r == eof:
 		return lexEndStatement
-	case r == '/':
-		if l.next() != '/' {
-			return l.errorf("invalid input (missing second / for comment)")
-		}
-		l.backup()
+	case r == '/' && l.peek() == '/':
 		l.backup()
 		l.emit(tokenEndStatement)
 		return lexLineComment
 	case r == '#':
-		fmt.Printf("Are we getting here?")
 		l.backup()
 		l.emit(tokenEndStatement)
 		return lexHashComment
 	case unicode.IsSpace(r):
-		for unicode.IsSpace(l.peek()) {
+		// The end of the line ends the statement so is not skipped.
+		for r := l.peek(); unicode.IsSpace(r) && !isEndOfLine(r); r = l.peek() {
 			l.next()
 		}
 		l.ignore()
-	case isAlphaNumeric(r):
+	case isValueChar(r):
 		return lexValue
 
 	default:
-		l.errorf("invalid shit yo.")
+		return l.errorf("invalid character %q in statement: %s", r, l.context(l.pos-l.width))
 	}
 	return lexValues
 }
 
+// lexValue scans an unquoted value. A "//" within it, as in the URL
+// http://host/path, is part of the value; only one starting a token
+// starts a line comment.
 func lexValue(l *lexer) stateFn {
-	for isAlphaNumeric(l.peek()) {
+	for isValueChar(l.peek()) {
 		l.next()
 	}
-	l.emit(tokenValue)
+	if isNumber(l.input[l.start:l.pos]) {
+		l.emit(tokenNumber)
+	} else {
+		l.emit(tokenValue)
+	}
 	return lexValues
 }
 
@@ -267,48 +498,92 @@ func lexSectionStart(l *lexer) stateFn {
 
 // lexQuote scans a quoted string.
 func lexQuote(l *lexer) stateFn {
-Loop:
+	if !l.scanQuote() {
+		return l.errorf("unterminated quoted string")
+	}
+	l.emit(tokenValue)
+	return lexValues
+}
+
+// lexWildcard scans a value that is a wildcard in angle brackets, such as
+// the unit <*> in a configuration group.
+func lexWildcard(l *lexer) stateFn {
+	if !l.scanWildcard() {
+		return l.errorf("unterminated wildcard: %s", l.context(l.start))
+	}
+	l.emit(tokenValue)
+	return lexValues
+}
+
+// scanWildcard scans the rest of a wildcard after the opening '<' and
+// reports whether it was terminated by a '>' on the same word.
+func (l *lexer) scanWildcard() bool {
+	for {
+		switch r := l.next(); {
+		case r == '>':
+			return true
+		case r == eof || unicode.IsSpace(r):
+			return false
+		}
+	}
+}
+
+// scanQuote scans the rest of a quoted string after the opening '"' and
+// reports whether it was terminated.
+func (l *lexer) scanQuote() bool {
 	for {
 		switch l.next() {
 		case '\\':
 			if r := l.next(); r != eof {
 				break
 			}
-			fallthrough
+			return false
 		case eof:
-			return l.errorf("unterminated quoted string")
+			return false
 		case '"':
-			break Loop
+			return true
 		}
 	}
-	l.emit(tokenValue)
-	return lexValues
 }
 
+// lexHashComment scans a comment starting with '#'. Exactly two hashes
+// start an annotation, which Junos uses for notes it adds to a statement
+// such as "## Last changed: ..." or "## SECRET-DATA". Any other number of
+// hashes, as in a "###" banner, is an ordinary comment.
 func lexHashComment(l *lexer) stateFn {
+	typ := tokenHashComment
+	l.fill(len(annotation) + len(hashComment))
+	if isAnnotation(l.input[l.pos:]) {
+		typ = tokenAnnotation
+	}
 	for {
 		r := l.next()
-		if r == '\n' || r == eof {
+		if isEndOfLine(r) || r == eof {
 			break
 		}
 	}
-	l.emit(tokenHashComment)
+	l.backup()
+	l.emit(typ)
 	return lexInsideSection
 }
 
 func lexLineComment(l *lexer) stateFn {
 	for {
 		r := l.next()
-		if r == '\n' || r == eof {
+		if isEndOfLine(r) || r == eof {
 			break
 		}
 	}
+	l.backup()
 	l.emit(tokenLineComment)
 	return lexInsideSection
 }
 
 func lexBlockComment(l *lexer) stateFn {
 	i := strings.Index(l.input[l.pos:], rightBlockComment)
+	for i < 0 && l.fill(len(l.input)-l.pos+1) {
+		i = strings.Index(l.input[l.pos:], rightBlockComment)
+	}
 	if i < 0 {
 		return l.errorf("unclosed comment")
 	}
@@ -318,9 +593,59 @@ func lexBlockComment(l *lexer) stateFn {
 	return lexInsideSection
 }
 
+// isAnnotation reports whether the comment text starts with exactly two
+// hashes.
+func isAnnotation(text string) bool {
+	return strings.HasPrefix(text, annotation) && !strings.HasPrefix(text, annotation+hashComment)
+}
+
+// isEndOfLine reports whether r ends a line. Lines may end in "\n", the
+// "\r\n" written by Windows tools or a lone '\r'.
+func isEndOfLine(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+// isNumber reports whether the unquoted value s is a decimal integer with
+// an optional leading '-' and an optional fraction, such as 1500, -1 or
+// 1.5. Anything else made of digits, such as the address 10.0.0.1, the
+// version 12.3R1 or the range 1-10, is not.
+func isNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, found := strings.Cut(s, ".")
+	return isDigits(whole) && (!found || isDigits(frac))
+}
+
+// isDigits reports whether s is a non-empty run of the digits 0-9.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func isAlphaNumeric(r rune) bool {
 	//	if strings.IndexRune("!#$%&|*+-/:<=>?@^_~", r) >= 0 {
 	//		return true
 	//	}
 	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
+
+// isKeywordChar reports whether r can be part of a keyword. In addition to
+// alphanumerics this allows the '.' and '/' found in interface names
+// (ge-0/0/0, ae0.100) and prefixes used as keywords (10.0.0.0/8).
+func isKeywordChar(r rune) bool {
+	return r == '.' || r == '/' || isAlphaNumeric(r)
+}
+
+// isValueChar reports whether r can be part of an unquoted value or
+// keyword. These additionally allow ':' for IPv6 addresses. A ':' ending
+// the leading word of a statement is instead treated as the end of a
+// modifier.
+func isValueChar(r rune) bool {
+	return r == ':' || isKeywordChar(r)
+}
@@ -1,8 +1,13 @@
 package jcfg
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 type lexTest struct {
@@ -90,6 +95,37 @@ var lexTests = []lexTest{
 		tESNewline,
 		tEOF,
 	}},
+	{"empty section no space", "section{}", []token{
+		token{tokenKeyword, 0, "section"},
+		tSectionStart,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"whitespace only section", "section {\n\n \t\r\n}", []token{
+		token{tokenKeyword, 0, "section"},
+		tSectionStart,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"comment only section", "section {\n    # only\n}", []token{
+		token{tokenKeyword, 0, "section"},
+		tSectionStart,
+		token{tokenHashComment, 0, "# only"},
+		tSectionEnd,
+		tEOF,
+	}},
+	{"statement at EOF", "section {\n    keyword value1;\n}\nkeyword value1", []token{
+		token{tokenKeyword, 0, "section"},
+		tSectionStart,
+		token{tokenKeyword, 0, "keyword"},
+		token{tokenValue, 0, "value1"},
+		tESColon,
+		tSectionEnd,
+		token{tokenKeyword, 0, "keyword"},
+		token{tokenValue, 0, "value1"},
+		tESEmpty,
+		tEOF,
+	}},
 	{"empty section", "section { }", []token{
 		token{tokenKeyword, 0, "section"},
 		tSectionStart,
@@ -112,11 +148,298 @@ var lexTests = []lexTest{
 		tESColon,
 		tEOF,
 	}},
+	{"ipv4 prefix", "address 10.0.0.1/24;", []token{
+		token{tokenKeyword, 0, "address"},
+		token{tokenValue, 0, "10.0.0.1/24"},
+		tESColon,
+		tEOF,
+	}},
+	{"ipv6 prefix", "route 2001:db8::/32;", []token{
+		token{tokenKeyword, 0, "route"},
+		token{tokenValue, 0, "2001:db8::/32"},
+		tESColon,
+		tEOF,
+	}},
+	{"prefix keyword", "ge-0/0/0 { 10.0.0.0/8; }", []token{
+		token{tokenKeyword, 0, "ge-0/0/0"},
+		tSectionStart,
+		token{tokenKeyword, 0, "10.0.0.0/8"},
+		tESColon,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"ipv6 prefix keyword", "prefix-list p { 2001:db8::/32; fe80::; }", []token{
+		token{tokenKeyword, 0, "prefix-list"},
+		token{tokenValue, 0, "p"},
+		tSectionStart,
+		token{tokenKeyword, 0, "2001:db8::/32"},
+		tESColon,
+		token{tokenKeyword, 0, "fe80::"},
+		tESColon,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"modifier before ipv6 keyword", "inactive: 2001:db8::/32;", []token{
+		token{tokenModifier, 0, "inactive"},
+		token{tokenKeyword, 0, "2001:db8::/32"},
+		tESColon,
+		tEOF,
+	}},
+	{"wildcard keyword and value", "<ge-*> { unit <*>; }", []token{
+		token{tokenKeyword, 0, "<ge-*>"},
+		tSectionStart,
+		token{tokenKeyword, 0, "unit"},
+		token{tokenValue, 0, "<*>"},
+		tESColon,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"unterminated wildcard", "unit <* 0;", []token{
+		token{tokenKeyword, 0, "unit"},
+		token{tokenError, 0, `unterminated wildcard: "unit <* 0;"`},
+	}},
+	{"number value", "mtu 1500;", []token{
+		token{tokenKeyword, 0, "mtu"},
+		token{tokenNumber, 0, "1500"},
+		tESColon,
+		tEOF,
+	}},
+	{"negative number value", "metric -1;", []token{
+		token{tokenKeyword, 0, "metric"},
+		token{tokenNumber, 0, "-1"},
+		tESColon,
+		tEOF,
+	}},
+	{"decimal number value", "threshold 1.5;", []token{
+		token{tokenKeyword, 0, "threshold"},
+		token{tokenNumber, 0, "1.5"},
+		tESColon,
+		tEOF,
+	}},
+	{"version is not a number", "version 12.3R1;", []token{
+		token{tokenKeyword, 0, "version"},
+		token{tokenValue, 0, "12.3R1"},
+		tESColon,
+		tEOF,
+	}},
+	{"address is not a number", "address 10.0.0.1;", []token{
+		token{tokenKeyword, 0, "address"},
+		token{tokenValue, 0, "10.0.0.1"},
+		tESColon,
+		tEOF,
+	}},
+	{"numbers and values", "vlan-id-list [ 10 1-10 - \"20\" ];", []token{
+		token{tokenKeyword, 0, "vlan-id-list"},
+		token{tokenListStart, 0, "["},
+		token{tokenNumber, 0, "10"},
+		token{tokenValue, 0, "1-10"},
+		token{tokenValue, 0, "-"},
+		token{tokenValue, 0, "\"20\""},
+		token{tokenListEnd, 0, "]"},
+		tESColon,
+		tEOF,
+	}},
+	{"time value", "time 23:59:00;", []token{
+		token{tokenKeyword, 0, "time"},
+		token{tokenValue, 0, "23:59:00"},
+		tESColon,
+		tEOF,
+	}},
+	{"mac address value", "mac 00:11:22:33:44:55;", []token{
+		token{tokenKeyword, 0, "mac"},
+		token{tokenValue, 0, "00:11:22:33:44:55"},
+		tESColon,
+		tEOF,
+	}},
+	{"modifier, colon values", "replace: at 23:59:00 fe80::1;", []token{
+		token{tokenModifier, 0, "replace"},
+		token{tokenKeyword, 0, "at"},
+		token{tokenValue, 0, "23:59:00"},
+		token{tokenValue, 0, "fe80::1"},
+		tESColon,
+		tEOF,
+	}},
+	{"modifier no space", "inactive:keyword1 value1;", []token{
+		token{tokenKeyword, 0, "inactive:keyword1"},
+		token{tokenValue, 0, "value1"},
+		tESColon,
+		tEOF,
+	}},
+	{"quoted keyword section", "\"foo bar\" { baz; }", []token{
+		token{tokenKeyword, 0, "\"foo bar\""},
+		tSectionStart,
+		token{tokenKeyword, 0, "baz"},
+		tESColon,
+		tSectionEnd,
+		tEOF,
+	}},
+	{"quoted keyword leaf", "\"show version\" brief;", []token{
+		token{tokenKeyword, 0, "\"show version\""},
+		token{tokenValue, 0, "brief"},
+		tESColon,
+		tEOF,
+	}},
+	{"modifier quoted keyword", "inactive: \"foo bar\";", []token{
+		token{tokenModifier, 0, "inactive"},
+		token{tokenKeyword, 0, "\"foo bar\""},
+		tESColon,
+		tEOF,
+	}},
+	{"unterminated quoted keyword", "\"foo bar", []token{
+		token{tokenError, 0, "unterminated quoted string"},
+	}},
+	{"multi-line quote", "message \"line one\nline two\";", []token{
+		token{tokenKeyword, 0, "message"},
+		token{tokenValue, 0, "\"line one\nline two\""},
+		tESColon,
+		tEOF,
+	}},
+	{"truncated leaf", "system { host-name foo bar", []token{
+		token{tokenKeyword, 0, "system"},
+		tSectionStart,
+		token{tokenKeyword, 0, "host-name"},
+		token{tokenValue, 0, "foo"},
+		token{tokenValue, 0, "bar"},
+		tESEmpty,
+		tEOF,
+	}},
+	{"truncated quoted value", "system { location \"lab", []token{
+		token{tokenKeyword, 0, "system"},
+		tSectionStart,
+		token{tokenKeyword, 0, "location"},
+		token{tokenError, 0, "unterminated quoted string"},
+	}},
+	{"truncated section", "system { ports {", []token{
+		token{tokenKeyword, 0, "system"},
+		tSectionStart,
+		token{tokenKeyword, 0, "ports"},
+		tSectionStart,
+		tEOF,
+	}},
+	{"crlf", "keyword value\r\n# hash\r\n// line\r\n", []token{
+		token{tokenKeyword, 0, "keyword"},
+		token{tokenValue, 0, "value"},
+		token{tokenEndStatement, 0, "\r"},
+		token{tokenHashComment, 0, "# hash"},
+		token{tokenLineComment, 0, "// line"},
+		tEOF,
+	}},
+	{"lone cr", "keyword1 value\rkeyword2 # hash\r", []token{
+		token{tokenKeyword, 0, "keyword1"},
+		token{tokenValue, 0, "value"},
+		token{tokenEndStatement, 0, "\r"},
+		token{tokenKeyword, 0, "keyword2"},
+		tESEmpty,
+		token{tokenHashComment, 0, "# hash"},
+		tEOF,
+	}},
+	{"two statements on a line", "keyword1 value1; keyword2 value2;", []token{
+		token{tokenKeyword, 0, "keyword1"},
+		token{tokenValue, 0, "value1"},
+		tESColon,
+		token{tokenKeyword, 0, "keyword2"},
+		token{tokenValue, 0, "value2"},
+		tESColon,
+		tEOF,
+	}},
+	{"three statements on a line", "keyword1 value1;keyword2; keyword3 value3", []token{
+		token{tokenKeyword, 0, "keyword1"},
+		token{tokenValue, 0, "value1"},
+		tESColon,
+		token{tokenKeyword, 0, "keyword2"},
+		tESColon,
+		token{tokenKeyword, 0, "keyword3"},
+		token{tokenValue, 0, "value3"},
+		tESEmpty,
+		tEOF,
+	}},
+	{"line comment at eof", "keyword;\n// comment", []token{
+		token{tokenKeyword, 0, "keyword"},
+		tESColon,
+		token{tokenLineComment, 0, "// comment"},
+		tEOF,
+	}},
+	{"hash comment at eof", "keyword;\n# comment", []token{
+		token{tokenKeyword, 0, "keyword"},
+		tESColon,
+		token{tokenHashComment, 0, "# comment"},
+		tEOF,
+	}},
+	{"hash comment", "# comment\n", []token{
+		token{tokenHashComment, 0, "# comment"},
+		tEOF,
+	}},
+	{"annotation", "## Last changed: 2024-01-01\nkeyword;", []token{
+		token{tokenAnnotation, 0, "## Last changed: 2024-01-01"},
+		token{tokenKeyword, 0, "keyword"},
+		tESColon,
+		tEOF,
+	}},
+	{"triple hash", "### banner ###\n", []token{
+		token{tokenHashComment, 0, "### banner ###"},
+		tEOF,
+	}},
+	{"annotation after statement", "keyword ## note", []token{
+		token{tokenKeyword, 0, "keyword"},
+		tESEmpty,
+		token{tokenAnnotation, 0, "## note"},
+		tEOF,
+	}},
+	{"trailing space", "keyword1 value \nkeyword2", []token{
+		token{tokenKeyword, 0, "keyword1"},
+		token{tokenValue, 0, "value"},
+		tESNewline,
+		token{tokenKeyword, 0, "keyword2"},
+		tESEmpty,
+		tEOF,
+	}},
+	{"list", "members [ a b ];", []token{
+		token{tokenKeyword, 0, "members"},
+		token{tokenListStart, 0, "["},
+		token{tokenValue, 0, "a"},
+		token{tokenValue, 0, "b"},
+		token{tokenListEnd, 0, "]"},
+		tESColon,
+		tEOF,
+	}},
+	{"list no space", "members [a\"b c\"];", []token{
+		token{tokenKeyword, 0, "members"},
+		token{tokenListStart, 0, "["},
+		token{tokenValue, 0, "a"},
+		token{tokenValue, 0, "\"b c\""},
+		token{tokenListEnd, 0, "]"},
+		tESColon,
+		tEOF,
+	}},
+	{"prefix, line comment", "address 10.0.0.1/24 // loopback", []token{
+		token{tokenKeyword, 0, "address"},
+		token{tokenValue, 0, "10.0.0.1/24"},
+		tESEmpty,
+		token{tokenLineComment, 0, "// loopback"},
+		tEOF,
+	}},
+	{"url value", "url http://x/y; // comment", []token{
+		token{tokenKeyword, 0, "url"},
+		token{tokenValue, 0, "http://x/y"},
+		tESColon,
+		token{tokenLineComment, 0, "// comment"},
+		tEOF,
+	}},
+	{"slashes within value", "address 10.0.0.1/24// loopback", []token{
+		token{tokenKeyword, 0, "address"},
+		token{tokenValue, 0, "10.0.0.1/24//"},
+		token{tokenValue, 0, "loopback"},
+		tESEmpty,
+		tEOF,
+	}},
 }
 
 func collect(t *lexTest) []token {
+	return collectLexer(lex(t.name, t.input))
+}
+
+func collectLexer(l *lexer) []token {
 	tokens := []token{}
-	l := lex(t.name, t.input)
 	for {
 		token := l.nextToken()
 		tokens = append(tokens, token)
@@ -210,3 +533,271 @@ func TestFileLex(t *testing.T) {
 		}
 	}
 }
+
+func TestLexReader(t *testing.T) {
+	for _, test := range lexTests {
+		t.Logf("Running test: %s", test.name)
+		l := lexReader(test.name, iotest.OneByteReader(strings.NewReader(test.input)))
+		tokens := collectLexer(l)
+		if !equal(tokens, test.tokens) {
+			t.Errorf("input: '%s'\n%s: got\n\t%+v\nexpected\n\t%v", test.input, test.name, tokens, test.tokens)
+		}
+	}
+}
+
+func TestLexReaderPosition(t *testing.T) {
+	// A large block comment spans several reads and is followed by
+	// multi-byte runes split across one byte reads.
+	input := "/*" + strings.Repeat("x\n", readSize) + "*/\nkeyword \"h\u00e9llo\u4e16\";\nlast;"
+	l := lexReader("position", iotest.OneByteReader(strings.NewReader(input)))
+	tokens := collectLexer(l)
+	expected := []token{
+		{tokenBlockComment, 0, input[:strings.Index(input, "*/")+2]},
+		{tokenKeyword, 0, "keyword"},
+		{tokenValue, 0, "\"h\u00e9llo\u4e16\""},
+		tESColon,
+		{tokenKeyword, 0, "last"},
+		tESColon,
+		tEOF,
+	}
+	if !equal(tokens, expected) {
+		t.Fatalf("got\n\t%+v\nexpected\n\t%v", tokens, expected)
+	}
+	last := tokens[4]
+	if line, col := l.lineNumber(last.pos), columnNumber(l.text(), last.pos); line != readSize+3 || col != 1 {
+		t.Errorf("last token at %d:%d, expected %d:%d", line, col, readSize+3, 1)
+	}
+}
+
+func TestLexReaderError(t *testing.T) {
+	r := iotest.DataErrReader(iotest.ErrReader(errors.New("boom")))
+	tokens := collectLexer(lexReader("error", r))
+	last := tokens[len(tokens)-1]
+	if last.typ != tokenError || last.val != "boom" {
+		t.Errorf("got %v, expected error token with value boom", last)
+	}
+}
+
+func TestLexAll(t *testing.T) {
+	for _, test := range lexTests {
+		tokens := lexAll(test.name, test.input)
+		if !equal(tokens, test.tokens) {
+			t.Errorf("input: '%s'\n%s: got\n\t%+v\nexpected\n\t%v", test.input, test.name, tokens, test.tokens)
+		}
+	}
+}
+
+// largeConfig is the factory configuration repeated to make a large input
+// for benchmarks.
+var largeConfig = strings.Repeat(factoryConfig, 1000)
+
+func BenchmarkLexNextToken(b *testing.B) {
+	b.SetBytes(int64(len(largeConfig)))
+	for i := 0; i < b.N; i++ {
+		collectLexer(lex("bench", largeConfig))
+	}
+}
+
+func BenchmarkLexAll(b *testing.B) {
+	b.SetBytes(int64(len(largeConfig)))
+	for i := 0; i < b.N; i++ {
+		lexAll("bench", largeConfig)
+	}
+}
+
+// deepConfig returns a configuration with sections nested depth deep,
+// each holding a list and a few leaves, repeated n times.
+func deepConfig(depth, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		for d := 0; d < depth; d++ {
+			fmt.Fprintf(&b, "%sgroup g%d-%d {\n", strings.Repeat("    ", d), i, d)
+			fmt.Fprintf(&b, "%s    members [ ge-0/0/%d ge-0/0/%d \"xe-0/0/%d\" ];\n", strings.Repeat("    ", d), d, d+1, d+2)
+			fmt.Fprintf(&b, "%s    description \"level %d\";\n", strings.Repeat("    ", d), d)
+			fmt.Fprintf(&b, "%s    address 2001:db8::%d/64;\n", strings.Repeat("    ", d), d)
+		}
+		for d := depth - 1; d >= 0; d-- {
+			fmt.Fprintf(&b, "%s}\n", strings.Repeat("    ", d))
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkLex(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		input string
+	}{
+		{"factory", factoryConfig},
+		{"deep", deepConfig(10, 100)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bm.input)))
+			for i := 0; i < b.N; i++ {
+				collectLexer(lex(bm.name, bm.input))
+			}
+		})
+	}
+}
+
+// BenchmarkLexLargeConfig lexes a configuration of several thousand
+// lines, of the size real devices emit.
+func BenchmarkLexLargeConfig(b *testing.B) {
+	input := deepConfig(10, 100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		collectLexer(lex("large", input))
+	}
+}
+
+func TestLexEdgePositions(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected []int
+	}{
+		{"a{}", []int{0, 1, 2, 3}},
+		{"a {\n    # only\n}", []int{0, 2, 8, 15, 16}},
+		{"a {\n\n \t\n}", []int{0, 2, 8, 9}},
+		{"a b", []int{0, 2, 3, 3}},
+		{"a {\n    b c", []int{0, 2, 8, 10, 11, 11}},
+	} {
+		var got []int
+		for _, tok := range lexAll("positions", test.input) {
+			got = append(got, tok.pos)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got\n\t%v\nexpected\n\t%v", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestLexCommentAtEOF(t *testing.T) {
+	for _, input := range []string{"// comment", "# comment", "## comment", "keyword; # comment"} {
+		tokens := collectLexer(lex("eof", input))
+		if len(tokens) < 2 {
+			t.Errorf("%q: got %v", input, tokens)
+			continue
+		}
+		comment, last := tokens[len(tokens)-2], tokens[len(tokens)-1]
+		if end := comment.pos + len(comment.val); end != len(input) || !strings.HasSuffix(input, comment.val) {
+			t.Errorf("%q: got comment %q ending at %d, expected it to end at %d", input, comment.val, end, len(input))
+		}
+		if last.typ != tokenEOF || last.pos != len(input) {
+			t.Errorf("%q: got last token %v at %d, expected EOF at %d", input, last, last.pos, len(input))
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, test := range lexTests {
+		f.Add(test.input)
+	}
+	f.Add(factoryConfig)
+	f.Fuzz(func(t *testing.T, input string) {
+		l := lex("fuzz", input)
+		// Every token but a final end of statement consumes input.
+		for i := 0; ; i++ {
+			if i > 2*len(input)+2 {
+				t.Fatalf("lexer did not terminate after %d tokens", i)
+			}
+			token := l.nextToken()
+			if token.pos < 0 || token.pos > len(input) {
+				t.Fatalf("%v: position %d out of range [0, %d]", token, token.pos, len(input))
+			}
+			if token.typ == tokenError {
+				break
+			}
+			if token.pos+len(token.val) > len(input) {
+				t.Fatalf("%v: value at %d overruns input of length %d", token, token.pos, len(input))
+			}
+			if token.typ == tokenEOF {
+				break
+			}
+		}
+	})
+}
+
+func TestLexMultilineQuote(t *testing.T) {
+	const input = "system {\n    login {\n        message \"Authorized use only.\r\n  Disconnect now\";\n        class ops;\n    }\n}\n"
+	const banner = "\"Authorized use only.\r\n  Disconnect now\""
+	for _, test := range []struct {
+		val  string
+		line int
+		col  int
+	}{
+		{"message", 3, 9},
+		{banner, 3, 17},
+		{";", 4, 18},
+		{"class", 5, 9},
+		{"ops", 5, 15},
+		{"}", 6, 5},
+	} {
+		found := false
+		for _, token := range lexAll("multiline", input) {
+			if token.val != test.val {
+				continue
+			}
+			found = true
+			line, col := lineNumber(input, token.pos), columnNumber(input, token.pos)
+			if line != test.line || col != test.col {
+				t.Errorf("%q: got %d:%d, expected %d:%d", test.val, line, col, test.line, test.col)
+			}
+			break
+		}
+		if !found {
+			t.Errorf("%q: token not found", test.val)
+		}
+	}
+
+	tree, err := Parse("multiline", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = "Authorized use only.\r\n  Disconnect now"
+	if got, _ := tree.GetString("system", "login", "message"); got != expected {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+}
+
+func TestRuneColumn(t *testing.T) {
+	const input = "a\tb\n\t\tc\nZürich d\n"
+	for _, test := range []struct {
+		pos, tabWidth, col int
+	}{
+		{strings.Index(input, "b"), 0, 3},
+		{strings.Index(input, "b"), 4, 5},
+		{strings.Index(input, "c"), 0, 3},
+		{strings.Index(input, "c"), 8, 17},
+		{strings.Index(input, "d"), 0, 8},
+		{strings.Index(input, "d"), 4, 8},
+	} {
+		if col := runeColumn(input, test.pos, test.tabWidth); col != test.col {
+			t.Errorf("%q at %d with tab width %d: got column %d, expected %d", input[test.pos:test.pos+1], test.pos, test.tabWidth, col, test.col)
+		}
+	}
+	if col := columnNumber(input, strings.Index(input, "d")); col != 9 {
+		t.Errorf("got byte column %d, expected 9", col)
+	}
+}
+
+func TestLineStart(t *testing.T) {
+	const input = "a;\r\nb;\rc;\n\nlong; line;"
+	l := lex("lines", input)
+	collectLexer(l)
+	// Ask out of order to check earlier lines are remembered.
+	for _, test := range []struct{ pos, expected int }{
+		{strings.Index(input, "line"), strings.Index(input, "long")},
+		{0, 0},
+		{strings.Index(input, "b"), strings.Index(input, "b")},
+		{strings.Index(input, "c;"), strings.Index(input, "c;")},
+		{strings.Index(input, "long") - 1, strings.Index(input, "long") - 1},
+		{1, 0},
+	} {
+		if got := l.lineStart(test.pos); got != test.expected {
+			t.Errorf("%d: got %d, expected %d", test.pos, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Rule checks a single statement for a problem. It is called with the
+// path to the statement, ending with the statement itself, and returns a
+// non-nil error describing the problem if there is one.
+type Rule func(path []string, n Node) error
+
+// Finding is a problem reported by a Rule.
+type Finding struct {
+	Path    []string // path to the statement, ending with the statement itself
+	Node    Node
+	Message string
+}
+
+func (f Finding) String() string {
+	return strings.Join(f.Path, " ") + ": " + f.Message
+}
+
+// Error implements error so that a Finding can be returned by Validate.
+func (f Finding) Error() string {
+	return f.String()
+}
+
+// Lint checks every statement in the tree against each of the rules and
+// returns the findings in the order the statements appear in the
+// configuration. Comments are not checked.
+func (t *Tree) Lint(rules ...Rule) []Finding {
+	var findings []Finding
+	Walk(t.Root, func(path []string, n Node) bool {
+		if len(path) == 0 || n.Type() == NodeComment {
+			return true
+		}
+		for _, rule := range rules {
+			if err := rule(path, n); err != nil {
+				findings = append(findings, Finding{Path: path, Node: n, Message: err.Error()})
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// DeprecatedRule returns a Rule reporting statements that use a deprecated
+// keyword. m maps each deprecated keyword to its suggested replacement, or
+// to "" if there is none. A key may also be a keyword followed by values,
+// such as "flag all", to deprecate only that form of a statement.
+func DeprecatedRule(m map[string]string) Rule {
+	return func(_ []string, n Node) error {
+		var keyword string
+		var values []string
+		switch n := n.(type) {
+		case *ValueNode:
+			keyword, values = n.Keyword, n.Values
+		case *SectionNode:
+			keyword, values = n.Keyword, n.Values
+		default:
+			return nil
+		}
+		for _, key := range []string{statement(keyword, values), keyword} {
+			replacement, ok := m[key]
+			if !ok {
+				continue
+			}
+			if replacement == "" {
+				return fmt.Errorf("%q is deprecated", key)
+			}
+			return fmt.Errorf("%q is deprecated, use %q instead", key, replacement)
+		}
+		return nil
+	}
+}
+
+// Validate checks the tree against each of the rules like Lint and
+// returns the findings as errors, each a Finding, or nil if there are
+// none.
+func (t *Tree) Validate(rules []Rule) []error {
+	var errs []error
+	for _, f := range t.Lint(rules...) {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// MatchRule returns a Rule calling check for the statements whose path
+// matches pattern, as for Match, and for no others.
+func MatchRule(pattern []string, check func(n Node) error) Rule {
+	return func(path []string, n Node) error {
+		if !matchPath(pattern, pathFields(path), equalString) {
+			return nil
+		}
+		return check(n)
+	}
+}
+
+// RequireChild returns a Rule reporting the sections matching pattern, as
+// for Match, that have no statement with keyword directly inside them,
+// such as RequireChild([]string{"interfaces", "*"}, "description") for
+// interfaces without a description. Leaf statements matching pattern are
+// not reported.
+func RequireChild(pattern []string, keyword string) Rule {
+	return MatchRule(pattern, func(n Node) error {
+		s, ok := n.(*SectionNode)
+		if !ok || len(s.ChildByKeyword(keyword)) > 0 {
+			return nil
+		}
+		return fmt.Errorf("missing %q", keyword)
+	})
+}
+
+// Duplicate is a set of leaf statements sharing a keyword in the same
+// section, such as two host-name statements.
+type Duplicate struct {
+	Path  []string     // path to the statements, ending with their keyword
+	Nodes []*ValueNode // the statements in the order they appear
+	Lines []int        // line of each of Nodes, or 0 if it was not parsed
+}
+
+func (d Duplicate) String() string {
+	lines := make([]string, len(d.Lines))
+	for i, l := range d.Lines {
+		lines[i] = fmt.Sprint(l)
+	}
+	return strings.Join(d.Path, " ") + ": duplicate statement on lines " + strings.Join(lines, ", ")
+}
+
+// Duplicates returns the leaf statements that share a keyword with another
+// leaf statement directly in the same section. They are listed section by
+// section in the order the sections appear, with those of a section
+// before those of the sections inside it. Sections are not compared, so the
+// named sections "file messages" and "file security" are not duplicates.
+// Leaves Junos allows to repeat with different values, such as the flag
+// statements of traceoptions, are reported all the same.
+func (t *Tree) Duplicates() []Duplicate {
+	var dups []Duplicate
+	Walk(t.Root, func(path []string, n Node) bool {
+		s, ok := n.(*SectionNode)
+		if !ok {
+			return true
+		}
+		var keywords []string
+		leaves := make(map[string][]*ValueNode)
+		for _, c := range s.Nodes {
+			if v, ok := c.(*ValueNode); ok {
+				if _, seen := leaves[v.Keyword]; !seen {
+					keywords = append(keywords, v.Keyword)
+				}
+				leaves[v.Keyword] = append(leaves[v.Keyword], v)
+			}
+		}
+		for _, k := range keywords {
+			if len(leaves[k]) < 2 {
+				continue
+			}
+			d := Duplicate{Path: appendPath(path, k), Nodes: leaves[k]}
+			for _, v := range d.Nodes {
+				line, _ := v.LineCol()
+				d.Lines = append(d.Lines, line)
+			}
+			dups = append(dups, d)
+		}
+		return true
+	})
+	return dups
+}
@@ -0,0 +1,163 @@
+package jcfg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tree, err := Parse("lint", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths [][]string
+	findings := tree.Lint(func(path []string, n Node) error {
+		paths = append(paths, path)
+		if v, ok := n.(*ValueNode); ok && v.Keyword == "any" {
+			return errors.New("any")
+		}
+		return nil
+	})
+	if len(paths) == 0 || !reflect.DeepEqual(paths[0], []string{"system"}) {
+		t.Errorf("first statement checked: got\n\t%q\nexpected\n\t%q", paths, []string{"system"})
+	}
+	for _, f := range findings {
+		if f.Node.(*ValueNode).Keyword != "any" || f.Path[len(f.Path)-1] != "any" || f.Message != "any" {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+	if len(findings) == 0 {
+		t.Error("expected findings for any")
+	}
+}
+
+func TestDeprecatedRule(t *testing.T) {
+	const input = `system {
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+    services {
+        telnet;
+        ssh;
+    }
+    processes {
+        traceoptions {
+            flag all;
+            flag events;
+        }
+    }
+}`
+	tree, err := Parse("deprecated", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := DeprecatedRule(map[string]string{
+		"telnet":   "ssh",
+		"flag all": "",
+	})
+	var got []string
+	for _, f := range tree.Lint(rule) {
+		got = append(got, f.String())
+	}
+	expected := []string{
+		`system services telnet: "telnet" is deprecated, use "ssh" instead`,
+		`system processes traceoptions flag: "flag all" is deprecated`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const input = `interfaces {
+    ge-0/0/0 {
+        description uplink;
+        unit 0 {
+            family inet;
+        }
+    }
+    ge-0/0/1 {
+        unit 0 {
+            description "server";
+        }
+    }
+    ge-0/0/2;
+}
+system {
+    host-name r1;
+}
+`
+	tree, err := Parse("validate", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := tree.Validate([]Rule{
+		RequireChild([]string{"interfaces", "*"}, "description"),
+		MatchRule([]string{"system", "host-name"}, func(n Node) error {
+			if v := n.(*ValueNode); len(v.Values) == 1 && v.Values[0] == "r1" {
+				return errors.New("default host name")
+			}
+			return nil
+		}),
+	})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	expected := []string{
+		`interfaces ge-0/0/1: missing "description"`,
+		"system host-name: default host name",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+	var f Finding
+	if len(errs) == 0 || !errors.As(errs[0], &f) || f.Node.(*SectionNode).name() != "ge-0/0/1" {
+		t.Errorf("got %v, expected a Finding for ge-0/0/1", errs)
+	}
+	if errs := tree.Validate(nil); errs != nil {
+		t.Errorf("got %v with no rules, expected nil", errs)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	const input = `system {
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+        }
+        file security {
+            any notice;
+        }
+    }
+    domain-name example.com;
+    host-name r2;
+}
+version 1.0;
+version 1.1;
+`
+	tree, err := Parse("duplicates", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range tree.Duplicates() {
+		got = append(got, d.String())
+		for i, v := range d.Nodes {
+			if v.Keyword != d.Path[len(d.Path)-1] {
+				t.Errorf("%v: node %d is %v", d, i, v)
+			}
+		}
+	}
+	expected := []string{
+		"version: duplicate statement on lines 14, 15",
+		"system host-name: duplicate statement on lines 2, 12",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+}
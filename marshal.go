@@ -0,0 +1,273 @@
+package jcfg
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// indent is the Junos standard indentation for one level of nesting.
+const indent = "    "
+
+// MarshalOptions controls how a Tree is written by Marshal.
+type MarshalOptions struct {
+	// OnSection, if non-nil, is called with the path of each top-level
+	// section just before it is written. It is purely observational and
+	// has no effect on the output, but allows for progress reporting when
+	// writing very large configurations.
+	OnSection func(path []string)
+}
+
+// Marshal writes the tree to w in the Junos curly-brace format.
+func (t *Tree) Marshal(w io.Writer, opts MarshalOptions) error {
+	p := &printer{w: w}
+	for _, n := range t.Root.Nodes {
+		if s, ok := n.(*SectionNode); ok && opts.OnSection != nil {
+			opts.OnSection([]string{s.name()})
+		}
+		p.printNode(n, 0)
+	}
+	return p.err
+}
+
+// String returns the tree in the Junos curly-brace format.
+func (t *Tree) String() string {
+	var b strings.Builder
+	t.Marshal(&b, MarshalOptions{})
+	return b.String()
+}
+
+// PrintOptions controls the layout of a Tree written by Format.
+type PrintOptions struct {
+	// Indent is the string used for each level of nesting, such as "\t"
+	// or a number of spaces. It takes precedence over IndentWidth and
+	// UseTabs.
+	Indent string
+	// IndentWidth is the number of spaces for each level of nesting. The
+	// default is the four spaces Junos uses.
+	IndentWidth int
+	// UseTabs indents each level of nesting with a tab instead of spaces.
+	UseTabs bool
+	// SortSections orders the sections within each section by name. Leaf
+	// statements, comments and the sections whose order is significant,
+	// as given by OrderedSections, keep their place between them.
+	SortSections bool
+	// OrderedSections are the patterns, as for Match, of the sections
+	// SortSections leaves in place. A nil slice means
+	// DefaultOrderedSections.
+	OrderedSections [][]string
+	// Compact writes a section with no statements on a single line as
+	// "keyword { }".
+	Compact bool
+	// Preserve writes each statement that has not been modified since it
+	// was parsed exactly as it appeared in the input, including its
+	// comments, whitespace and the order of anything below it, so that
+	// only the edited statements of a configuration change. A section
+	// with a modified statement below it is written with its own lines
+	// formatted and its unmodified statements preserved.
+	Preserve bool
+	// VerbatimComments writes the continuation lines of a comment spanning
+	// several lines exactly as they appeared in the input instead of
+	// indenting them along with its first line.
+	VerbatimComments bool
+	// CommentStyle rewrites comments on a single line in the given
+	// syntax, such as "# note" as "/* note */" with CommentStyleBlock.
+	// Annotations and block comments spanning several lines are written
+	// as they are. The default, CommentStylePreserve, keeps the syntax
+	// each comment was written in.
+	CommentStyle CommentStyle
+}
+
+// Format returns the tree in the Junos curly-brace format laid out
+// according to opts.
+func (t *Tree) Format(opts PrintOptions) string {
+	var b strings.Builder
+	t.WriteConfig(&b, opts)
+	return b.String()
+}
+
+// WriteConfig writes the tree to w in the Junos curly-brace format laid
+// out according to opts. Unless opts.VerbatimComments is set, the
+// continuation lines of a comment spanning several lines are indented
+// along with its first line, keeping their alignment relative to it.
+func (t *Tree) WriteConfig(w io.Writer, opts PrintOptions) error {
+	p := &printer{w: w, opts: opts}
+	for _, n := range p.order(t.Root.Nodes) {
+		p.printNode(n, 0)
+	}
+	return p.err
+}
+
+// Oneline returns the tree in the Junos curly-brace format on a single
+// line, with statements separated by spaces, as in
+// "system { host-name r1; ports { console; } }". This suits log messages
+// and quick comparisons. Comments are dropped since a "#" or "//" comment
+// would swallow the rest of the line.
+func (t *Tree) Oneline() string {
+	var b strings.Builder
+	oneline(&b, t.Root.Nodes)
+	return b.String()
+}
+
+// oneline writes the statements of nodes to b separated by spaces.
+func oneline(b *strings.Builder, nodes []Node) {
+	for _, n := range statements(nodes) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		switch n := n.(type) {
+		case *ValueNode:
+			b.WriteString(n.String())
+		case *SectionNode:
+			b.WriteString(modify(n.Modifier, n.name()) + " {")
+			oneline(b, n.Nodes)
+			b.WriteString(" }")
+		}
+	}
+}
+
+// printer writes nodes to an io.Writer. The first error encountered is
+// kept in err and all following writes are skipped.
+type printer struct {
+	w    io.Writer
+	err  error
+	opts PrintOptions
+	sums map[Node][32]byte // digests of statements for opts.Preserve
+	path []string          // path of the section being printed
+}
+
+// indent returns the string written for depth levels of nesting.
+func (p *printer) indent(depth int) string {
+	ind := p.opts.Indent
+	switch {
+	case ind != "":
+	case p.opts.UseTabs:
+		ind = "\t"
+	case p.opts.IndentWidth > 0:
+		ind = strings.Repeat(" ", p.opts.IndentWidth)
+	default:
+		ind = indent
+	}
+	return strings.Repeat(ind, depth)
+}
+
+// line writes s on its own line indented to depth.
+func (p *printer) line(depth int, s string) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = io.WriteString(p.w, p.indent(depth)+s+"\n")
+}
+
+// comment writes the comment c indented to depth. Each further line of a
+// block comment has the indentation the comment started with in the input
+// replaced so that it lines up with the first line.
+func (p *printer) comment(c *CommentNode, depth int) {
+	if text := restyle(c.Text, p.opts.CommentStyle); text != c.Text {
+		p.line(depth, text)
+		return
+	}
+	lines := strings.Split(c.Text, "\n")
+	if !c.indented || p.opts.VerbatimComments {
+		p.line(depth, c.Text)
+		return
+	}
+	ind := p.indent(depth)
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], c.indent) {
+			lines[i] = ind + lines[i][len(c.indent):]
+		}
+	}
+	p.line(depth, strings.Join(lines, "\n"))
+}
+
+// order returns nodes in the order they are printed in.
+func (p *printer) order(nodes []Node) []Node {
+	if !p.opts.SortSections {
+		return nodes
+	}
+	var slots []int
+	var sections []*SectionNode
+	for i, n := range nodes {
+		if s, ok := n.(*SectionNode); ok && !orderedSection(p.opts.OrderedSections, appendPath(p.path, s.name())) {
+			slots = append(slots, i)
+			sections = append(sections, s)
+		}
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].name() < sections[j].name()
+	})
+	sorted := append([]Node(nil), nodes...)
+	for i, s := range sections {
+		sorted[slots[i]] = s
+	}
+	return sorted
+}
+
+func (p *printer) printNode(n Node, depth int) {
+	if p.opts.Preserve && p.unmodified(n) {
+		p.source(nodeSource(n), depth)
+		return
+	}
+	switch n := n.(type) {
+	case *ValueNode:
+		p.printComments(n.Comments, depth)
+		p.line(depth, n.String())
+	case *SectionNode:
+		p.printComments(n.Comments, depth)
+		if p.opts.Compact && len(n.Nodes) == 0 {
+			p.line(depth, modify(n.Modifier, n.name())+" { }")
+			return
+		}
+		p.line(depth, modify(n.Modifier, n.name())+" {")
+		p.path = append(p.path, n.name())
+		for _, c := range p.order(n.Nodes) {
+			p.printNode(c, depth+1)
+		}
+		p.path = p.path[:len(p.path)-1]
+		p.line(depth, "}")
+	case *CommentNode:
+		p.comment(n, depth)
+	}
+}
+
+// unmodified reports whether the statement n is unchanged since it was
+// parsed.
+func (p *printer) unmodified(n Node) bool {
+	parsed, ok := parsedSum(n)
+	return ok && p.sum(n) == parsed
+}
+
+// sum returns the digest of the statement n as it is now.
+func (p *printer) sum(n Node) [32]byte {
+	if s, ok := p.sums[n]; ok {
+		return s
+	}
+	if p.sums == nil {
+		p.sums = make(map[Node][32]byte)
+	}
+	s := digest(n, p.sum)
+	p.sums[n] = s
+	return s
+}
+
+// source writes the original text of a statement at depth.
+func (p *printer) source(src *source, depth int) {
+	if p.err != nil {
+		return
+	}
+	text := src.text
+	if !src.indented {
+		text = p.indent(depth) + text
+	}
+	if !isEndOfLine(rune(text[len(text)-1])) {
+		text += "\n"
+	}
+	_, p.err = io.WriteString(p.w, text)
+}
+
+func (p *printer) printComments(comments []*CommentNode, depth int) {
+	for _, c := range comments {
+		p.comment(c, depth)
+	}
+}
@@ -0,0 +1,415 @@
+package jcfg
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalOnSection(t *testing.T) {
+	input := "system { host-name r1; }\nversion 12;\ninterfaces { ge-0 { mtu 9000; } }\nfile messages { any notice; }\n"
+	tree, err := Parse("onsection", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths [][]string
+	var b strings.Builder
+	err = tree.Marshal(&b, MarshalOptions{
+		OnSection: func(path []string) {
+			paths = append(paths, path)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{{"system"}, {"interfaces"}, {"file messages"}}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", paths, expected)
+	}
+	if b.String() != tree.String() {
+		t.Errorf("OnSection changed the output: got\n%s\nexpected\n%s", b.String(), tree.String())
+	}
+}
+
+func TestFormat(t *testing.T) {
+	const input = `system {
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+    host-name r1;
+    login { }
+    # keep
+    archival { }
+}
+interfaces {
+    ge-0/0/1 { mtu 9192; }
+    ge-0/0/0 { }
+}
+`
+	tree, err := Parse("format", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		opts     PrintOptions
+		expected string
+	}{
+		{"default", PrintOptions{}, tree.String()},
+		{"tabs", PrintOptions{Indent: "\t"},
+			"system {\n\tsyslog {\n\t\tfile messages {\n\t\t\tany notice;\n\t\t}\n\t}\n\thost-name r1;\n\tlogin {\n\t}\n\t# keep\n\tarchival {\n\t}\n}\n" +
+				"interfaces {\n\tge-0/0/1 {\n\t\tmtu 9192;\n\t}\n\tge-0/0/0 {\n\t}\n}\n"},
+		{"sorted compact", PrintOptions{Indent: "  ", SortSections: true, Compact: true},
+			"interfaces {\n  ge-0/0/0 { }\n  ge-0/0/1 {\n    mtu 9192;\n  }\n}\n" +
+				"system {\n  # keep\n  archival { }\n  host-name r1;\n  login { }\n  syslog {\n    file messages {\n      any notice;\n    }\n  }\n}\n"},
+	} {
+		if got := tree.Format(test.opts); got != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestFormatSortOrdered(t *testing.T) {
+	tree, err := Parse("ordered", "firewall { filter f { term b; term a { then accept; } } } policy-options { policy-statement p { term z; term y; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `firewall {
+    filter f {
+        term b;
+        term a {
+            then accept;
+        }
+    }
+}
+policy-options {
+    policy-statement p {
+        term z;
+        term y;
+    }
+}
+`
+	if got := tree.Format(PrintOptions{SortSections: true}); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+	tree, err = Parse("ordered", "firewall { filter f { term b { } term a { } } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const sorted = "firewall {\n    filter f {\n        term a { }\n        term b { }\n    }\n}\n"
+	if got := tree.Format(PrintOptions{SortSections: true, Compact: true, OrderedSections: [][]string{}}); got != sorted {
+		t.Errorf("got\n%s\nexpected\n%s", got, sorted)
+	}
+	tree, err = Parse("ordered", "firewall { filter f { term b { } term a { } } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const kept = "firewall {\n    filter f {\n        term b { }\n        term a { }\n    }\n}\n"
+	if got := tree.Format(PrintOptions{SortSections: true, Compact: true}); got != kept {
+		t.Errorf("got\n%s\nexpected\n%s", got, kept)
+	}
+}
+
+func TestStringInactive(t *testing.T) {
+	const input = `interfaces {
+    ge-0/0/0 {
+        description uplink;
+        inactive: unit 0 {
+            description "old uplink";
+            family inet {
+                address 192.0.2.1/24;
+            }
+        }
+        unit 10 {
+            inactive: family inet6;
+        }
+    }
+}
+`
+	// Parse the statements from a single line so that String has to lay
+	// them out, inactive: prefixes included, to match input.
+	tree, err := Parse("inactive", strings.Join(strings.Fields(input), " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("got\n%s\nexpected\n%s", result, input)
+	}
+
+	n, ok := tree.Get("interfaces", "ge-0/0/0", "unit 0")
+	if !ok {
+		t.Fatal("unit 0 not found")
+	}
+	unit := n.(*SectionNode)
+	if unit.Modifier != "inactive" {
+		t.Errorf("got modifier %q, expected %q", unit.Modifier, "inactive")
+	}
+	Walk(unit, func(path []string, n Node) bool {
+		if n == Node(unit) {
+			return true
+		}
+		var modifier string
+		switch n := n.(type) {
+		case *ValueNode:
+			modifier = n.Modifier
+		case *SectionNode:
+			modifier = n.Modifier
+		}
+		if modifier != "" {
+			t.Errorf("%q: got modifier %q inside an inactive section, expected none", path, modifier)
+		}
+		return true
+	})
+}
+
+func TestWriteConfigIndent(t *testing.T) {
+	const input = `system {
+    /* managed by automation,
+     * do not edit */
+    host-name r1;
+    name-server [ 192.0.2.1 192.0.2.2 ];
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+}
+`
+	tree, err := Parse("indent", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		opts     PrintOptions
+		expected string
+	}{
+		{"default", PrintOptions{}, input},
+		{"two spaces", PrintOptions{IndentWidth: 2}, `system {
+  /* managed by automation,
+   * do not edit */
+  host-name r1;
+  name-server [ 192.0.2.1 192.0.2.2 ];
+  syslog {
+    file messages {
+      any notice;
+    }
+  }
+}
+`},
+		{"tabs", PrintOptions{UseTabs: true, IndentWidth: 2},
+			"system {\n\t/* managed by automation,\n\t * do not edit */\n\thost-name r1;\n\tname-server [ 192.0.2.1 192.0.2.2 ];\n" +
+				"\tsyslog {\n\t\tfile messages {\n\t\t\tany notice;\n\t\t}\n\t}\n}\n"},
+	} {
+		var b strings.Builder
+		if err := tree.WriteConfig(&b, test.opts); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if got := b.String(); got != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestWriteConfigBlockComment(t *testing.T) {
+	const input = `system {
+syslog {
+/* forwarded to the collector,
+   see the runbook
+   before changing */
+file messages { any notice; }
+}
+}
+`
+	tree, err := Parse("comment", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		opts     PrintOptions
+		expected string
+	}{
+		{"reindented", PrintOptions{}, `system {
+    syslog {
+        /* forwarded to the collector,
+           see the runbook
+           before changing */
+        file messages {
+            any notice;
+        }
+    }
+}
+`},
+		{"verbatim", PrintOptions{VerbatimComments: true}, `system {
+    syslog {
+        /* forwarded to the collector,
+   see the runbook
+   before changing */
+        file messages {
+            any notice;
+        }
+    }
+}
+`},
+	} {
+		if got := tree.Format(test.opts); got != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestWriteConfigCommentStyle(t *testing.T) {
+	const input = `## Last changed: 2024-01-01 10:00:00 UTC
+# the system
+system {
+    /* managed by
+       automation */
+    host-name r1;
+    syslog {
+        /* messages */
+        file messages {
+            any notice;
+            // left over
+        }
+    }
+}
+`
+	tree, err := Parse("comment", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		style    CommentStyle
+		expected string
+	}{
+		{"preserve", CommentStylePreserve, input},
+		{"hash", CommentStyleHash, `## Last changed: 2024-01-01 10:00:00 UTC
+# the system
+system {
+    /* managed by
+       automation */
+    host-name r1;
+    syslog {
+        # messages
+        file messages {
+            any notice;
+            # left over
+        }
+    }
+}
+`},
+		{"block", CommentStyleBlock, `## Last changed: 2024-01-01 10:00:00 UTC
+/* the system */
+system {
+    /* managed by
+       automation */
+    host-name r1;
+    syslog {
+        /* messages */
+        file messages {
+            any notice;
+            /* left over */
+        }
+    }
+}
+`},
+	} {
+		if got := tree.Format(PrintOptions{CommentStyle: test.style}); got != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestOneline(t *testing.T) {
+	tree, err := Parse("oneline", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `system { syslog { file messages { any notice; authorization info; } file interactive-commands { interactive-commands any; } user "*" { any emergency; } } }`
+	if got := tree.Oneline(); got != expected {
+		t.Errorf("got\n\t%s\nexpected\n\t%s", got, expected)
+	}
+
+	tree, err = Parse("comments", "# hash\ninactive: system {\n    login { }\n    // line\n    members [ a b ];\n}\nversion 1;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expectedComments = `inactive: system { login { } members [ a b ]; } version 1;`
+	if got := tree.Oneline(); got != expectedComments {
+		t.Errorf("got\n\t%s\nexpected\n\t%s", got, expectedComments)
+	}
+}
+
+// structuralTokens returns the tokens of input with whitespace normalized
+// so that formatting differences do not matter: statement ends are
+// compared by type alone and runs of whitespace within a token are
+// collapsed.
+func structuralTokens(input string) []token {
+	tokens := lexAll("roundtrip", input)
+	for i, tok := range tokens {
+		tok.pos = 0
+		if tok.typ == tokenEndStatement {
+			tok.val = ""
+		}
+		tok.val = strings.Join(strings.Fields(tok.val), " ")
+		tokens[i] = tok
+	}
+	return tokens
+}
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.config*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no fixtures found")
+	}
+	for _, file := range files {
+		var r io.Reader
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = f
+		if strings.HasSuffix(file, ".gz") {
+			if r, err = gzip.NewReader(f); err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+		}
+		input, err := io.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		tree, err := Parse(file, string(input))
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		output := tree.String()
+		reparsed, err := Parse(file, output)
+		if err != nil {
+			t.Errorf("%s: reparsing output: %v\n%s", file, err, output)
+			continue
+		}
+		if !reparsed.EqualWithOptions(tree, EqualOptions{Ordered: true}) {
+			t.Errorf("%s: reparsed tree differs:\n%s", file, output)
+		}
+		got, expected := structuralTokens(output), structuralTokens(string(input))
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: tokens differ:\n\t%v\nexpected\n\t%v", file, got, expected)
+		}
+	}
+}
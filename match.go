@@ -0,0 +1,137 @@
+package jcfg
+
+import (
+	"strings"
+)
+
+// Match returns the statements whose path matches pattern, in the order
+// they appear in the configuration.
+//
+// Unlike the segments of a path given to Get, the keyword of a section
+// and its identifying values are matched by separate segments of the
+// pattern, so "file messages" is matched by the two segments "file" and
+// "messages". A "*" segment matches any single keyword or identifier and
+// a "**" segment matches any number of statements, including none. A
+// section is also matched by a pattern ending with its keyword, which
+// leaves its identifier unconstrained. For example
+//
+//	t.Match("interfaces", "*", "unit", "*")
+//
+// returns every unit of every interface, as does
+// t.Match("interfaces", "*", "unit"), and t.Match("**", "description")
+// returns every description statement. An identifier may also be given
+// in the same segment as its keyword, as with Get.
+func (t *Tree) Match(pattern ...string) []Node {
+	return t.match(pattern, equalString)
+}
+
+// MatchFold is like Match but compares the segments of pattern with the
+// keywords and values of statements case-insensitively, as by
+// strings.EqualFold.
+func (t *Tree) MatchFold(pattern ...string) []Node {
+	return t.match(pattern, strings.EqualFold)
+}
+
+// match returns the statements whose path matches pattern comparing
+// segments with eq.
+func (t *Tree) match(pattern []string, eq func(a, b string) bool) []Node {
+	var nodes []Node
+	// fields holds the fields of the statements along the path to the one
+	// being visited, taken from the statements themselves as Walk reaches
+	// them in pre-order.
+	var fields [][]string
+	Walk(t.Root, func(path []string, n Node) bool {
+		if len(path) == 0 {
+			return true
+		}
+		fields = append(fields[:len(path)-1], nodeFields(n))
+		if matchPath(pattern, fields, eq) {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
+// matchPath reports whether pattern matches path, comparing segments with
+// eq. Each element of path holds the fields of a statement: the keyword
+// of a leaf, or the keyword and identifying values of a section.
+func matchPath(pattern []string, path [][]string, eq func(a, b string) bool) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:], eq) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	fields := path[0]
+	if eq(pattern[0], strings.Join(fields, " ")) {
+		return matchPath(pattern[1:], path[1:], eq)
+	}
+	if !matchSegment(pattern[0], fields[:1], eq) {
+		return false
+	}
+	pattern = pattern[1:]
+	if len(fields) > 1 && len(pattern) > 0 && pattern[0] != "**" {
+		if !matchSegment(pattern[0], fields[1:], eq) {
+			return false
+		}
+		pattern = pattern[1:]
+	}
+	return matchPath(pattern, path[1:], eq)
+}
+
+// matchSegment reports whether a single pattern segment matches fields,
+// either a keyword or the identifying values of a section.
+func matchSegment(segment string, fields []string, eq func(a, b string) bool) bool {
+	if segment == "*" || eq(segment, strings.Join(fields, " ")) {
+		return true
+	}
+	return len(fields) == 1 && eq(segment, Unquote(fields[0]))
+}
+
+// nodeFields returns the fields of the statement n as matchPath takes
+// them.
+func nodeFields(n Node) []string {
+	if s, ok := n.(*SectionNode); ok {
+		return append([]string{s.Keyword}, s.Values...)
+	}
+	return []string{n.(*ValueNode).Keyword}
+}
+
+// pathFields splits each segment of path, as passed to a Walk function,
+// into the keyword and values it was made from. These are separated by
+// spaces, and a keyword or value with a space in it is quoted.
+func pathFields(path []string) [][]string {
+	fields := make([][]string, len(path))
+	for i, segment := range path {
+		for j := 0; j < len(segment); {
+			switch end := strings.IndexByte(segment[j:], ' '); {
+			case segment[j] == ' ':
+				j++
+				continue
+			case segment[j] == '"' && quoteEnd(segment, j) > 0:
+				end = quoteEnd(segment, j)
+				fields[i] = append(fields[i], segment[j:end])
+				j = end
+			case end < 0:
+				fields[i] = append(fields[i], segment[j:])
+				j = len(segment)
+			default:
+				fields[i] = append(fields[i], segment[j:j+end])
+				j += end
+			}
+		}
+		if fields[i] == nil {
+			fields[i] = []string{segment}
+		}
+	}
+	return fields
+}
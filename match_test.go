@@ -0,0 +1,189 @@
+package jcfg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	const input = `system {
+    syslog {
+        file messages {
+            any notice;
+        }
+        file security {
+            authorization info;
+        }
+        user "*" {
+            any emergency;
+        }
+    }
+}
+interfaces {
+    ge-0/0/0 {
+        description uplink;
+        unit 0 {
+            description "uplink v4";
+        }
+        unit 10;
+    }
+    ge-0/0/1 {
+        unit 0 {
+            family inet;
+        }
+    }
+}
+`
+	tree, err := Parse("match", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		pattern  []string
+		expected []string
+	}{
+		{[]string{"interfaces", "*", "unit", "*"}, []string{"unit 0", "unit 0"}},
+		{[]string{"interfaces", "*", "unit"}, []string{"unit 0", "unit", "unit 0"}},
+		{[]string{"interfaces", "ge-0/0/0", "unit", "0"}, []string{"unit 0"}},
+		{[]string{"interfaces", "*", "unit 0", "family"}, []string{"family"}},
+		{[]string{"system", "syslog", "file", "*"}, []string{"file messages", "file security"}},
+		{[]string{"system", "syslog", "*"}, []string{"file messages", "file security", `user "*"`}},
+		{[]string{"system", "syslog", "user", "*", "any"}, []string{"any"}},
+		{[]string{"system", "syslog", "*", "*", "any"}, []string{"any", "any"}},
+		{[]string{"**", "description"}, []string{"description", "description"}},
+		{[]string{"interfaces", "**", "unit", "0"}, []string{"unit 0", "unit 0"}},
+		{[]string{"system", "**"}, []string{"system", "syslog", "file messages", "any", "file security", "authorization", `user "*"`, "any"}},
+		// no matches
+		{[]string{"interfaces", "*", "unit", "5"}, nil},
+		{[]string{"system", "syslog", "file", "any"}, nil},
+		{[]string{"snmp", "**"}, nil},
+		{[]string{"*", "*", "*", "*", "*", "*", "*"}, nil},
+		{nil, nil},
+	} {
+		var got []string
+		for _, n := range tree.Match(test.pattern...) {
+			switch n := n.(type) {
+			case *ValueNode:
+				got = append(got, n.Keyword)
+			case *SectionNode:
+				got = append(got, n.name())
+			}
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got\n\t%q\nexpected\n\t%q", test.pattern, got, test.expected)
+		}
+	}
+}
+
+func TestMatchFold(t *testing.T) {
+	tree, err := Parse("match", "interfaces {\n    ge-0/0/0 {\n        Description uplink;\n    }\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Match("Interfaces", "*", "description"); len(got) != 0 {
+		t.Errorf("Match ignored case: %v", got)
+	}
+	got := tree.MatchFold("Interfaces", "GE-0/0/0", "description")
+	if len(got) != 1 || got[0].(*ValueNode).Keyword != "Description" {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", got, "[Description]")
+	}
+}
+
+func TestMatchInterfaces(t *testing.T) {
+	tree, err := ParseFile("testdata/interfaces.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		pattern  []string
+		expected []string
+	}{
+		{[]string{"interfaces", "*", "unit", "*"}, []string{"unit 0", "unit 100", "unit 200", "unit 0"}},
+		{[]string{"interfaces", "*"}, []string{"ge-0/0/0", "ge-0/0/1", "lo0"}},
+		{[]string{"**", "address"}, []string{"address", "address", "address"}},
+		{[]string{"**", "family"}, []string{"family inet", "family inet", "family", "family inet"}},
+		{[]string{"interfaces", "ge-0/0/1", "unit", "*", "vlan-id"}, []string{"vlan-id", "vlan-id"}},
+		{[]string{"interfaces", "*", "unit", "0", "**", "address"}, []string{"address", "address"}},
+		{[]string{"interfaces", "lo0", "**"}, []string{"lo0", "unit 0", "family inet", "address"}},
+	} {
+		var got []string
+		for _, n := range tree.Match(test.pattern...) {
+			switch n := n.(type) {
+			case *ValueNode:
+				got = append(got, n.Keyword)
+			case *SectionNode:
+				got = append(got, n.name())
+			}
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got\n\t%q\nexpected\n\t%q", test.pattern, got, test.expected)
+		}
+	}
+}
+
+func TestMatchQuotedKeyword(t *testing.T) {
+	const input = `event-options {
+    policy p {
+        then {
+            execute-commands {
+                commands {
+                    "show version" {
+                        brief;
+                    }
+                    "show interfaces" terse {
+                        detail;
+                    }
+                }
+            }
+        }
+    }
+}
+system {
+    login {
+        user "a b" {
+            class super-user;
+        }
+    }
+}
+`
+	tree, err := Parse("match", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		pattern  []string
+		expected []string
+	}{
+		{[]string{"**", "show version", "*"}, []string{"brief"}},
+		{[]string{"**", "commands", "show interfaces", "terse", "*"}, []string{"detail"}},
+		{[]string{"**", "commands", "show interfaces"}, []string{`"show interfaces" terse`}},
+		{[]string{"**", "commands", "show"}, nil},
+		{[]string{"**", `"show`}, nil},
+		{[]string{"system", "login", "user", "a b", "class"}, []string{"class"}},
+		{[]string{"system", "login", "user", "a"}, nil},
+	} {
+		var got []string
+		for _, n := range tree.Match(test.pattern...) {
+			switch n := n.(type) {
+			case *ValueNode:
+				got = append(got, n.Keyword)
+			case *SectionNode:
+				got = append(got, n.name())
+			}
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got\n\t%q\nexpected\n\t%q", test.pattern, got, test.expected)
+		}
+	}
+	if got := tree.MatchFold("**", "SHOW VERSION", "brief"); len(got) != 1 {
+		t.Errorf("MatchFold: got %v, expected brief", got)
+	}
+
+	findings := tree.Lint(MatchRule([]string{"**", "show interfaces", "terse"}, func(n Node) error {
+		return fmt.Errorf("matched")
+	}))
+	if len(findings) != 1 || findings[0].Node.(*SectionNode).Keyword != `"show interfaces"` {
+		t.Errorf("MatchRule: got %v, expected the show interfaces section", findings)
+	}
+}
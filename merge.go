@@ -0,0 +1,224 @@
+package jcfg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge merges other into t the way the Junos "load merge" command merges
+// a configuration into the candidate. Sections are combined recursively
+// and statements not already in t are appended after the existing ones,
+// which keep their place, so the order of sections whose order matters,
+// such as firewall filter terms, is never changed by a merge.
+// A leaf in other replaces the values of the leaf with the same keyword in
+// t, except that lists are combined: the values of t are kept and any new
+// values appended, unless the incoming list carries the "replace:"
+// modifier in which case its values replace the existing ones. Likewise a
+// section with "replace:" is not combined with the section it matches in
+// t but takes its place, with everything below it; if there is no such
+// section it is simply added. A leaf
+// repeated with different values, such as "name-server", is treated as a
+// whole: the leaves in other replace all of those in t.
+//
+// A statement in other with the "delete:" modifier is not merged but
+// instead removes the matching statements, and everything below them,
+// from t. They are matched as a path segment is by Get, so "delete: file;"
+// removes every "file" section while "delete: file messages;" removes
+// only that one. Deleting a statement that is not in t is not an error.
+// Both modifiers are acted on at every level of other, including within a
+// section that is added or replaced, and never appear in the result.
+// Any other modifier, such as "inactive:", is copied onto the merged
+// statement.
+// Comments preceding an incoming statement replace those of the statement
+// it is merged into; standalone comments in other are ignored.
+//
+// Merge returns an error, leaving t unchanged, if a statement is a section
+// in one tree and a leaf statement in the other.
+func (t *Tree) Merge(other *Tree) error {
+	root := copyNode(t, t.Root).(*SectionNode)
+	if err := mergeSection(nil, root, other.Root); err != nil {
+		return err
+	}
+	t.Root = root
+	return nil
+}
+
+// Modifiers that only instruct a merge and are never kept in the result.
+const (
+	modifierDelete  = "delete"
+	modifierReplace = "replace"
+)
+
+// mergeSection merges the statements of src into dst, which is at path.
+func mergeSection(path []string, dst, src *SectionNode) error {
+	// merged holds the last leaf merged for each keyword so that a
+	// repeated leaf in src is kept along with the first rather than
+	// replacing it.
+	merged := make(map[string]Node)
+	for _, n := range statements(src.Nodes) {
+		if deleteKey, ok := mergeDelete(n); ok {
+			for _, c := range dst.lookup(deleteKey) {
+				dst.remove(c)
+			}
+			continue
+		}
+		key := mergeKey(n)
+		p := appendPath(path, key)
+		existing := dst.mergeMatch(key)
+		if existing != nil && existing.Type() != n.Type() {
+			return fmt.Errorf("jcfg: merge %s: section in one tree, leaf statement in the other", strings.Join(p, " "))
+		}
+		switch n := n.(type) {
+		case *ValueNode:
+			if prev, ok := merged[key]; ok {
+				c, err := mergeCopy(p, dst.tr, n)
+				if err != nil {
+					return err
+				}
+				dst.insertAfter(prev, c)
+				merged[key] = c
+				continue
+			}
+			if existing == nil {
+				c, err := mergeCopy(p, dst.tr, n)
+				if err != nil {
+					return err
+				}
+				dst.append(c)
+				merged[key] = c
+				continue
+			}
+			mergeValue(existing.(*ValueNode), n)
+			// The leaves of src replace every leaf of dst with the keyword.
+			for _, c := range dst.lookup(key) {
+				if c != existing && c.Type() == NodeValue {
+					dst.remove(c)
+				}
+			}
+			merged[key] = existing
+		case *SectionNode:
+			if existing == nil {
+				c, err := mergeCopy(p, dst.tr, n)
+				if err != nil {
+					return err
+				}
+				dst.append(c)
+				continue
+			}
+			s := existing.(*SectionNode)
+			if n.Modifier == modifierReplace {
+				c, err := mergeCopy(p, dst.tr, n)
+				if err != nil {
+					return err
+				}
+				if c := c.(*SectionNode); len(c.Comments) == 0 {
+					c.Comments = s.Comments
+				}
+				dst.insertAfter(s, c)
+				dst.remove(s)
+				continue
+			}
+			mergeAttrs(s.tr, &s.Comments, &s.Modifier, n.Comments, n.Modifier)
+			if err := mergeSection(p, s, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeDelete reports whether n carries the "delete:" modifier and, if so,
+// returns the path segment of the statements it deletes. The statement
+// "delete: file messages;" deletes the section "file messages".
+func mergeDelete(n Node) (string, bool) {
+	switch n := n.(type) {
+	case *ValueNode:
+		return statement(n.Keyword, n.Values), n.Modifier == modifierDelete
+	case *SectionNode:
+		return n.name(), n.Modifier == modifierDelete
+	}
+	return "", false
+}
+
+// mergeValue merges the leaf src into the existing leaf dst.
+func mergeValue(dst, src *ValueNode) {
+	mergeAttrs(dst.tr, &dst.Comments, &dst.Modifier, src.Comments, src.Modifier)
+	if dst.List && src.List && src.Modifier != modifierReplace {
+		for _, v := range src.Values {
+			if !contains(dst.Values, v) {
+				dst.Values = append(dst.Values, v)
+			}
+		}
+		return
+	}
+	dst.Values = copyStrings(src.Values)
+	dst.List = src.List
+}
+
+// mergeAttrs copies the comments and modifier of an incoming statement
+// onto the statement of tr it is merged into, if it has any.
+func mergeAttrs(tr *Tree, comments *[]*CommentNode, modifier *string, srcComments []*CommentNode, srcModifier string) {
+	if len(srcComments) > 0 {
+		*comments = copyComments(tr, srcComments)
+	}
+	if srcModifier != "" && srcModifier != modifierReplace {
+		*modifier = srcModifier
+	}
+}
+
+// mergeCopy returns a copy of n, which is at path, belonging to tr to be
+// added by a merge. A "replace:" modifier has done its job once the
+// statement is added and is dropped. The statements below a section are
+// merged into an empty copy of it rather than copied so that the
+// modifiers within it are acted on too.
+func mergeCopy(path []string, tr *Tree, n Node) (Node, error) {
+	switch n := n.(type) {
+	case *ValueNode:
+		c := copyNode(tr, n).(*ValueNode)
+		if c.Modifier == modifierReplace {
+			c.Modifier = ""
+		}
+		return c, nil
+	case *SectionNode:
+		c := tr.newSection(n.Pos, n.Keyword, copyStrings(n.Values))
+		c.Comments = copyComments(tr, n.Comments)
+		c.src = n.src
+		if n.Modifier != modifierReplace {
+			c.Modifier = n.Modifier
+		}
+		if err := mergeSection(path, c, n); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return copyNode(tr, n), nil
+}
+
+// mergeKey returns the key matching statements are found by: the keyword
+// of a leaf or the name of a section.
+func mergeKey(n Node) string {
+	if s, ok := n.(*SectionNode); ok {
+		return s.name()
+	}
+	return n.(*ValueNode).Keyword
+}
+
+// mergeMatch returns the first statement of s with the given merge key,
+// or nil if there is none.
+func (s *SectionNode) mergeMatch(key string) Node {
+	for _, n := range statements(s.Nodes) {
+		if mergeKey(n) == key {
+			return n
+		}
+	}
+	return nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
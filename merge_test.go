@@ -0,0 +1,160 @@
+package jcfg
+
+import (
+	"testing"
+)
+
+type mergeTest struct {
+	name     string
+	base     string
+	other    string
+	expected string
+}
+
+var mergeTests = []mergeTest{
+	{"ordered terms", `firewall { filter f { term b { then accept; } term a { then discard; } } }`,
+		`firewall { filter f { term a { then reject; } term c { then accept; } } }`,
+		"firewall {\n    filter f {\n        term b {\n            then accept;\n        }\n        term a {\n            then reject;\n        }\n        term c {\n            then accept;\n        }\n    }\n}\n"},
+	{"disjoint", `system { host-name r1; }`, `snmp { community public; }`,
+		"system {\n    host-name r1;\n}\nsnmp {\n    community public;\n}\n"},
+	{"overlapping sections", `system {
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+}`, `system {
+    syslog {
+        file messages {
+            authorization info;
+        }
+        file security {
+            any any;
+        }
+    }
+    time-zone UTC;
+}`, `system {
+    host-name r1;
+    syslog {
+        file messages {
+            any notice;
+            authorization info;
+        }
+        file security {
+            any any;
+        }
+    }
+    time-zone UTC;
+}
+`},
+	{"syslog file added", `system {
+    syslog {
+        user "*" {
+            any emergency;
+        }
+        file messages {
+            any notice;
+        }
+    }
+}`, `system {
+    syslog {
+        file security {
+            authorization info;
+            interactive-commands any;
+        }
+    }
+}`, `system {
+    syslog {
+        user "*" {
+            any emergency;
+        }
+        file messages {
+            any notice;
+        }
+        file security {
+            authorization info;
+            interactive-commands any;
+        }
+    }
+}
+`},
+	{"repeated leaves replaced", `system { name-server 192.0.2.1; host-name r1; name-server 192.0.2.2; }`,
+		`system { name-server 198.51.100.1; name-server 198.51.100.2; name-server 198.51.100.3; }`,
+		"system {\n    name-server 198.51.100.1;\n    name-server 198.51.100.2;\n    name-server 198.51.100.3;\n    host-name r1;\n}\n"},
+	{"repeated leaves added", `system { host-name r1; }`,
+		`system { name-server 198.51.100.1; name-server 198.51.100.2; }`,
+		"system {\n    host-name r1;\n    name-server 198.51.100.1;\n    name-server 198.51.100.2;\n}\n"},
+	{"leaf overwritten", `system { host-name r1; domain-name example.com; }`, `system { host-name r2; }`,
+		"system {\n    host-name r2;\n    domain-name example.com;\n}\n"},
+	{"list union", `vlan { members [ a b ]; }`, `vlan { members [ b c ]; }`,
+		"vlan {\n    members [ a b c ];\n}\n"},
+	{"list replace", `vlan { members [ a b ]; }`, `vlan { replace: members [ b c ]; }`,
+		"vlan {\n    members [ b c ];\n}\n"},
+	{"new list", `vlan { id 10; }`, `vlan { replace: members [ a ]; }`,
+		"vlan {\n    id 10;\n    members [ a ];\n}\n"},
+	{"replace section", `system { host-name r1; syslog { file messages { any notice; } user "*" { any emergency; } } ports { console; } }`,
+		`system { replace: syslog { file security { any any; } } }`,
+		"system {\n    host-name r1;\n    syslog {\n        file security {\n            any any;\n        }\n    }\n    ports {\n        console;\n    }\n}\n"},
+	{"replace absent section", `system { host-name r1; }`, `system { replace: syslog { file messages { any notice; } } }`,
+		"system {\n    host-name r1;\n    syslog {\n        file messages {\n            any notice;\n        }\n    }\n}\n"},
+	{"replace keeps comments", "system {\n    # logging\n    syslog { file messages { any notice; } }\n}", `system { replace: syslog { user "*" { any emergency; } } }`,
+		"system {\n    # logging\n    syslog {\n        user \"*\" {\n            any emergency;\n        }\n    }\n}\n"},
+	{"delete leaf", `system { host-name r1; time-zone UTC; }`, `system { delete: host-name; }`,
+		"system {\n    time-zone UTC;\n}\n"},
+	{"delete and add", `system { syslog { file messages { any notice; } file security { any any; } } }`,
+		`system { syslog { delete: file security; file audit { any info; } } }`,
+		"system {\n    syslog {\n        file messages {\n            any notice;\n        }\n        file audit {\n            any info;\n        }\n    }\n}\n"},
+	{"delete section", `system { services { ssh; } ports { console; } }`, `system { delete: services; }`,
+		"system {\n    ports {\n        console;\n    }\n}\n"},
+	{"delete missing", `system { host-name r1; }`, `delete: snmp;`,
+		"system {\n    host-name r1;\n}\n"},
+	{"nested delete in new section", `system { host-name r1; }`, `snmp { community public; delete: location; trap-group t { delete: version; replace: targets { 10.0.0.1; } } }`,
+		"system {\n    host-name r1;\n}\nsnmp {\n    community public;\n    trap-group t {\n        targets {\n            10.0.0.1;\n        }\n    }\n}\n"},
+	{"nested modifiers in replaced section", `system { syslog { file messages { any notice; } } }`,
+		`system { replace: syslog { delete: file messages; replace: user "*" { any emergency; } } }`,
+		"system {\n    syslog {\n        user \"*\" {\n            any emergency;\n        }\n    }\n}\n"},
+	{"modifier copied", `interfaces { ge-0/0/0 { mtu 1500; } }`, `interfaces { inactive: ge-0/0/0 { mtu 9192; } }`,
+		"interfaces {\n    inactive: ge-0/0/0 {\n        mtu 9192;\n    }\n}\n"},
+	{"comments replaced", "/* old */\nsystem { host-name r1; }", "/* new */\nsystem { host-name r2; }",
+		"/* new */\nsystem {\n    host-name r2;\n}\n"},
+}
+
+func TestMerge(t *testing.T) {
+	for _, test := range mergeTests {
+		base, err := Parse(test.name, test.base)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		other, err := Parse(test.name, test.other)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if err := base.Merge(other); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got := base.String(); got != test.expected {
+			t.Errorf("%s: got\n\t%q\nexpected\n\t%q", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	const input = `system { host-name r1; ports { console; } }`
+	base, err := Parse("base", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Parse("other", `system { host-name r2; ports; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := base.Merge(other); err == nil {
+		t.Error("expected an error merging a leaf into a section")
+	}
+	expected, _ := Parse("expected", input)
+	if !base.EqualWithOptions(expected, EqualOptions{Ordered: true}) {
+		t.Errorf("tree modified by failed merge:\n%s", base)
+	}
+}
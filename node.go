@@ -1,23 +1,295 @@
 package jcfg
 
+import (
+	"fmt"
+	"strings"
+)
+
+// A Node is an element in the parse tree.
 type Node interface {
 	Type() NodeType
 	String() string
+	// Position returns the byte offset of the node in the original input.
 	Position() int
 	tree() *Tree
 }
 
+//go:generate stringer -type=NodeType -output=nodetype_string.go
+
+// NodeType identifies the type of a parse tree node.
 type NodeType int
 
+// Type returns itself and provides an easy default implementation
+// for embedding in a Node.
+func (t NodeType) Type() NodeType {
+	return t
+}
+
 const (
-	NodeValue NoteType = iota
-	NodeSection
+	NodeValue   NodeType = iota // A leaf statement with zero or more values
+	NodeSection                 // A section containing other statements
+	NodeComment                 // A line, hash or block comment
 )
 
+// Pos represents a byte position in the original input text from which
+// the configuration was parsed.
+type Pos int
+
+// NoPos is the position of a node that was not parsed from text, such as
+// one added by Set or built by a Builder.
+const NoPos Pos = -1
+
+// Position returns the byte position as an int.
+func (p Pos) Position() int {
+	return int(p)
+}
+
+// ValueNode holds a leaf statement such as "host-name r1;". A statement
+// with no values (e.g. "disable;") is a flag.
+type ValueNode struct {
+	NodeType
+	Pos
+	tr       *Tree
+	Comments []*CommentNode // comments directly preceding the statement
+	Modifier string         // modifier such as "inactive" or "protect", if any
+	Keyword  string
+	Values   []string
+	List     bool // values are written as a list as in "members [ a b ];"
+	src      source
+}
+
+func (t *Tree) newValue(pos Pos, keyword string, values []string) *ValueNode {
+	return &ValueNode{tr: t, NodeType: NodeValue, Pos: pos, Keyword: keyword, Values: values}
+}
+
+func (v *ValueNode) String() string {
+	if v.List {
+		return modify(v.Modifier, statement(v.Keyword, listValues(v.Values))) + ";"
+	}
+	return modify(v.Modifier, statement(v.Keyword, v.Values)) + ";"
+}
+
+// GoString implements fmt.GoStringer, showing the node type and the
+// statement, as in NodeValue("host-name r1;"), for the %#v verb.
+func (v *ValueNode) GoString() string {
+	return fmt.Sprintf("%s(%q)", v.NodeType, v.String())
+}
+
+// Unquoted returns the values with any quoting removed as by Unquote.
+// Values holds the values exactly as written in the configuration.
+func (v *ValueNode) Unquoted() []string {
+	return unquoteValues(v.Values)
+}
+
+// LineCol returns the line and column, both starting at 1, of the
+// statement in the text it was parsed from. See Tree.LineCol.
+func (v *ValueNode) LineCol() (line, col int) {
+	return v.tr.LineCol(int(v.Pos))
+}
+
+func (v *ValueNode) tree() *Tree {
+	return v.tr
+}
+
+// SectionNode holds a section such as "file messages { ... }". The values
+// between the keyword and the opening brace identify the section. The
+// root of a Tree is a SectionNode with no keyword.
 type SectionNode struct {
-	Nodes []Node
+	NodeType
+	Pos
+	tr       *Tree
+	Comments []*CommentNode // comments directly preceding the section
+	Modifier string         // modifier such as "inactive" or "protect", if any
+	Keyword  string
+	Values   []string
+	Nodes    []Node
+	src      source
+}
+
+func (t *Tree) newSection(pos Pos, keyword string, values []string) *SectionNode {
+	return &SectionNode{tr: t, NodeType: NodeSection, Pos: pos, Keyword: keyword, Values: values}
+}
+
+func (s *SectionNode) append(n Node) {
+	s.Nodes = append(s.Nodes, n)
+}
+
+func (s *SectionNode) String() string {
+	var b strings.Builder
+	p := &printer{w: &b}
+	if s.Keyword == "" {
+		// The root section has no braces of its own.
+		for _, n := range s.Nodes {
+			p.printNode(n, 0)
+		}
+	} else {
+		p.printNode(s, 0)
+	}
+	return b.String()
+}
+
+// GoString implements fmt.GoStringer, showing the node type, the name of
+// the section and how many nodes are below it, as in
+// NodeSection("file messages", 2), for the %#v verb.
+func (s *SectionNode) GoString() string {
+	return fmt.Sprintf("%s(%q, %d)", s.NodeType, modify(s.Modifier, s.name()), len(s.Nodes))
+}
+
+// LineCol returns the line and column, both starting at 1, of the
+// section in the text it was parsed from. See Tree.LineCol.
+func (s *SectionNode) LineCol() (line, col int) {
+	return s.tr.LineCol(int(s.Pos))
+}
+
+func (s *SectionNode) tree() *Tree {
+	return s.tr
+}
+
+// name returns the section keyword followed by its identifying values,
+// which is how a section is addressed in a path.
+func (s *SectionNode) name() string {
+	return statement(s.Keyword, s.Values)
+}
+
+// CommentNode holds a comment. Text is the comment exactly as it appeared
+// in the input including the leading "#", "##", "//" or "/*". Comments
+// directly preceding a statement are attached to that statement; only
+// comments not followed by a statement in the same section appear in its
+// Nodes. A "##" annotation, which Junos uses for notes about the
+// statement that follows, is attached the same way and marked by its
+// Attachment.
+type CommentNode struct {
+	NodeType
+	Pos
+	tr         *Tree
+	Text       string
+	Attachment CommentAttachment
+	indent     string // whitespace before the comment on its line in the input
+	indented   bool   // the comment started its line in the input
+}
+
+// CommentAttachment describes how a comment relates to the statements
+// around it.
+type CommentAttachment int
+
+const (
+	CommentLeading    CommentAttachment = iota // Attached to the statement it precedes
+	CommentTrailing                            // At the end of a section, after its statements
+	CommentAnnotation                          // A "##" annotation attached to the statement it precedes
+)
+
+func (t *Tree) newComment(pos Pos, text string) *CommentNode {
+	c := &CommentNode{tr: t, NodeType: NodeComment, Pos: pos, Text: text}
+	if t.lex != nil {
+		input := t.lex.text()
+		if lineStart := t.lex.lineStart(int(pos)); isIndentation(input, lineStart, int(pos)) {
+			c.indent, c.indented = input[lineStart:pos], true
+		}
+	}
+	if isAnnotation(text) {
+		c.Attachment = CommentAnnotation
+	}
+	return c
+}
+
+func (c *CommentNode) String() string {
+	return c.Text
+}
+
+// GoString implements fmt.GoStringer, showing the node type and the text
+// of the comment, as in NodeComment("# note"), for the %#v verb.
+func (c *CommentNode) GoString() string {
+	return fmt.Sprintf("%s(%q)", c.NodeType, c.Text)
+}
+
+func (c *CommentNode) tree() *Tree {
+	return c.tr
+}
+
+// statement joins a keyword and its values the way they appear in a
+// configuration.
+func statement(keyword string, values []string) string {
+	if len(values) == 0 {
+		return keyword
+	}
+	return keyword + " " + strings.Join(values, " ")
+}
+
+// listValues returns values enclosed in the brackets of a list.
+func listValues(values []string) []string {
+	l := make([]string, 0, len(values)+2)
+	l = append(l, "[")
+	l = append(l, values...)
+	return append(l, "]")
+}
+
+// modify prefixes stmt with modifier, if any, the way Junos displays it
+// (e.g. "inactive: interfaces").
+func modify(modifier, stmt string) string {
+	if modifier == "" {
+		return stmt
+	}
+	return modifier + ": " + stmt
+}
+
+// appendPath returns a new path with elem added to the end. The result
+// never shares its backing array with path so it is safe to retain.
+func appendPath(path []string, elem string) []string {
+	p := make([]string, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, elem)
+}
+
+// Clone returns a deep copy of the tree sharing no nodes or slices with
+// t, including the values, comments and modifiers of every statement, so
+// that either can be changed without affecting the other.
+func (t *Tree) Clone() *Tree {
+	c := &Tree{Name: t.Name, text: t.text, cols: t.cols}
+	c.Root = copyNode(c, t.Root).(*SectionNode)
+	return c
+}
+
+// copyNode returns a deep copy of n belonging to tr.
+func copyNode(tr *Tree, n Node) Node {
+	switch n := n.(type) {
+	case *ValueNode:
+		v := *n
+		v.tr = tr
+		v.Comments = copyComments(tr, n.Comments)
+		v.Values = copyStrings(n.Values)
+		return &v
+	case *SectionNode:
+		s := *n
+		s.tr = tr
+		s.Comments = copyComments(tr, n.Comments)
+		s.Values = copyStrings(n.Values)
+		s.Nodes = nil
+		for _, c := range n.Nodes {
+			s.append(copyNode(tr, c))
+		}
+		return &s
+	case *CommentNode:
+		c := *n
+		c.tr = tr
+		return &c
+	}
+	panic("jcfg: unknown node type")
+}
+
+func copyComments(tr *Tree, comments []*CommentNode) []*CommentNode {
+	if comments == nil {
+		return nil
+	}
+	c := make([]*CommentNode, len(comments))
+	for i, comment := range comments {
+		c[i] = copyNode(tr, comment).(*CommentNode)
+	}
+	return c
 }
 
-func (l *SectionNode) append(n Node) {
-	l.Nodes = append(l.Nodes, n)
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
 }
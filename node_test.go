@@ -0,0 +1,118 @@
+package jcfg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	const input = `# the system
+system {
+    inactive: host-name r1;
+    name-server [ 192.0.2.1 192.0.2.2 ];
+    syslog {
+        /* messages */
+        file messages {
+            any notice;
+        }
+    }
+}
+`
+	tree, err := Parse("clone", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tree.Clone()
+	if result := c.String(); result != input {
+		t.Errorf("got\n%s\nexpected\n%s", result, input)
+	}
+
+	if err := c.Set([]string{"system", "host-name"}, "r2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set([]string{"system", "syslog", "file messages", "any"}, "warning"); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := c.Get("system", "name-server")
+	n.(*ValueNode).Values[0] = "198.51.100.1"
+	n.(*ValueNode).Modifier = "protect"
+	n, _ = c.Get("system", "syslog", "file messages")
+	n.(*SectionNode).Comments[0].Text = "/* changed */"
+	n, _ = c.Get("system")
+	s := n.(*SectionNode)
+	s.Comments[0].Text = "# changed"
+	s.Values = append(s.Values, "extra")
+
+	if result := tree.String(); result != input {
+		t.Errorf("original changed by editing the clone:\n%s", result)
+	}
+	Walk(c.Root, func(path []string, n Node) bool {
+		if n.tree() != c {
+			t.Errorf("%q: node belongs to another tree", path)
+		}
+		return true
+	})
+}
+
+func TestCloneDelete(t *testing.T) {
+	tree, err := Parse("clone", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := tree.String()
+	c := tree.Clone()
+	if !c.Delete("system", "syslog") {
+		t.Fatal("system syslog not deleted from the clone")
+	}
+	if err := c.Set([]string{"system", "host-name"}, "candidate"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("system", "syslog"); ok {
+		t.Error("system syslog still in the clone")
+	}
+	if n, ok := c.Get("system", "host-name"); !ok || n.String() != "host-name candidate;" {
+		t.Errorf("got %v from the clone, expected host-name candidate;", n)
+	}
+	if _, ok := tree.Get("system", "syslog"); !ok {
+		t.Error("system syslog deleted from the original")
+	}
+	if result := tree.String(); result != original {
+		t.Errorf("original changed by editing the clone:\n%s", result)
+	}
+}
+
+func TestNodeTypeString(t *testing.T) {
+	for _, test := range []struct {
+		typ      NodeType
+		expected string
+	}{
+		{NodeValue, "NodeValue"},
+		{NodeSection, "NodeSection"},
+		{NodeComment, "NodeComment"},
+		{NodeType(7), "NodeType(7)"},
+	} {
+		if got := test.typ.String(); got != test.expected {
+			t.Errorf("got %q, expected %q", got, test.expected)
+		}
+	}
+}
+
+func TestGoString(t *testing.T) {
+	tree, err := Parse("gostring", "# note\ninactive: file messages { any notice; }\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tree.Root.Nodes[0].(*SectionNode)
+	for _, test := range []struct {
+		n        Node
+		expected string
+	}{
+		{s, `NodeSection("inactive: file messages", 1)`},
+		{s.Nodes[0], `NodeValue("any notice;")`},
+		{s.Comments[0], `NodeComment("# note")`},
+	} {
+		if got := fmt.Sprintf("%#v", test.n); got != test.expected {
+			t.Errorf("got %s, expected %s", got, test.expected)
+		}
+	}
+}
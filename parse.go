@@ -0,0 +1,344 @@
+package jcfg
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+// Tree is the representation of a single parsed configuration.
+type Tree struct {
+	Name string       // name of the configuration, usually the file name
+	Root *SectionNode // top-level root of the tree
+	text string       // text parsed to create the tree
+	cols columns      // how columns are counted
+	// Parsing only; cleared after parse.
+	lex       *lexer
+	token     token // one-token lookahead for the parser
+	peekCount int
+	// noLineComments makes "//" comments an error.
+	noLineComments bool
+}
+
+// New allocates a new, empty parse tree with the given name.
+func New(name string) *Tree {
+	t := &Tree{Name: name}
+	t.Root = t.newSection(NoPos, "", nil)
+	return t
+}
+
+// Parse parses the configuration in input and returns the resulting Tree.
+func Parse(name, input string) (*Tree, error) {
+	t := New(name)
+	if err := t.parse(lex(name, input)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseOptions controls how ParseWithOptions parses a configuration.
+type ParseOptions struct {
+	// AllowLineComments accepts "//" comments. Junos only writes "#" and
+	// "/* */" comments, so without this a "//" comment is a syntax error,
+	// which is useful when checking configuration taken from a device.
+	// Parse always allows them.
+	AllowLineComments bool
+	// RuneColumns counts the columns of a SyntaxError, and those returned
+	// by LineCol, in runes rather than bytes, as editors do, so that a
+	// multi-byte character is a single column.
+	RuneColumns bool
+	// TabWidth, if positive and RuneColumns is set, expands tabs so that a
+	// tab moves to the next column after a multiple of TabWidth. Otherwise
+	// a tab is a single column.
+	TabWidth int
+}
+
+// columns is how a tree counts the columns of positions in its text.
+type columns struct {
+	runes    bool // count runes rather than bytes
+	tabWidth int  // expand tabs to this width when counting runes
+}
+
+// column returns the column, starting at 1, of the byte offset pos within
+// its line of input.
+func (c columns) column(input string, pos int) int {
+	if !c.runes {
+		return columnNumber(input, pos)
+	}
+	return runeColumn(input, pos, c.tabWidth)
+}
+
+// ParseWithOptions is like Parse but with options controlling what input
+// is accepted. The zero ParseOptions is stricter than Parse.
+func ParseWithOptions(name, input string, opts ParseOptions) (*Tree, error) {
+	t := New(name)
+	t.noLineComments = !opts.AllowLineComments
+	t.cols = columns{runes: opts.RuneColumns, tabWidth: opts.TabWidth}
+	if err := t.parse(lex(name, input)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseReader parses the configuration read from r. The input is consumed
+// as the parser needs it rather than being read in full up front.
+func ParseReader(name string, r io.Reader) (*Tree, error) {
+	t := New(name)
+	if err := t.parse(lexReader(name, r)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// gzipMagic is the header every gzip stream starts with.
+const gzipMagic = "\x1f\x8b"
+
+// ParseFile reads and parses the configuration in the named file. A file
+// compressed with gzip, as archived configurations often are, is
+// recognized by its contents and decompressed while it is parsed.
+func ParseFile(filename string) (*Tree, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(len(gzipMagic)); string(magic) == gzipMagic {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return ParseReader(filename, r)
+}
+
+// LineCol returns the line and column, both starting at 1, of the byte
+// position pos in the text the tree was parsed from, such as the position
+// of one of its nodes. It returns 0, 0 if pos is outside of the text,
+// which includes NoPos, the position of any node not created by parsing
+// text, and any position in a tree not created by parsing text. The
+// column counts bytes unless the tree was parsed with RuneColumns set in
+// its ParseOptions.
+func (t *Tree) LineCol(pos int) (line, col int) {
+	if pos < 0 || pos > len(t.text) || t.text == "" {
+		return 0, 0
+	}
+	return lineNumber(t.text, pos), t.cols.column(t.text, pos)
+}
+
+// next returns the next token.
+func (t *Tree) next() token {
+	if t.peekCount > 0 {
+		t.peekCount--
+	} else {
+		t.token = t.lex.nextToken()
+	}
+	return t.token
+}
+
+// backup backs the input stream up one token.
+func (t *Tree) backup() {
+	t.peekCount++
+}
+
+// SyntaxError is the error returned when parsing input that is not a valid
+// configuration. For a section left open at the end of the input the
+// position is that of the section's keyword rather than the end of input.
+type SyntaxError struct {
+	Name   string // name of the configuration, as passed to Parse
+	Line   int    // line of the error, starting at 1
+	Column int    // column of the error in bytes, or as set by ParseOptions, starting at 1
+	Msg    string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Column, e.Msg)
+}
+
+// errorf formats the error and terminates processing.
+func (t *Tree) errorf(pos int, format string, args ...interface{}) {
+	t.Root = nil
+	panic(&SyntaxError{
+		Name:   t.Name,
+		Line:   t.lex.lineNumber(pos),
+		Column: t.cols.column(t.lex.input, pos),
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+// unexpected complains about the token and terminates processing.
+func (t *Tree) unexpected(tok token, context string) {
+	t.errorf(tok.pos, "unexpected %s in %s", tok, context)
+}
+
+// recover is the handler that turns panics into returns from the top
+// level of Parse.
+func (t *Tree) recover(errp *error) {
+	e := recover()
+	if e != nil {
+		if _, ok := e.(runtime.Error); ok {
+			panic(e)
+		}
+		t.stopParse()
+		*errp = e.(error)
+	}
+}
+
+// startParse initializes the parser, using the lexer.
+func (t *Tree) startParse(lex *lexer) {
+	t.lex = lex
+	t.peekCount = 0
+}
+
+// stopParse terminates parsing, releasing the lexer if it has not yet
+// reached the end of its input.
+func (t *Tree) stopParse() {
+	t.lex.stop()
+	t.lex = nil
+}
+
+// parse is the top-level parser for a configuration. It runs to EOF.
+func (t *Tree) parse(lex *lexer) (err error) {
+	defer t.recover(&err)
+	t.startParse(lex)
+	t.parseSection(t.Root)
+	t.text = lex.input
+	t.stopParse()
+	return nil
+}
+
+// parseSection parses the statements of a section up to and including
+// the closing '}', or EOF for the root section.
+func (t *Tree) parseSection(s *SectionNode) {
+	// comments are held until the next statement and attached to it as
+	// leading comments. Any left at the end of the section stand alone.
+	var comments []*CommentNode
+	for {
+		switch tok := t.next(); tok.typ {
+		case tokenEOF:
+			if s != t.Root {
+				t.errorf(int(s.Pos), "unexpected EOF, expected '}'")
+			}
+			appendComments(s, comments)
+			return
+		case tokenSectionEnd:
+			if s == t.Root {
+				t.errorf(tok.pos, "unexpected '}'")
+			}
+			appendComments(s, comments)
+			return
+		case tokenLineComment, tokenHashComment, tokenAnnotation, tokenBlockComment:
+			if tok.typ == tokenLineComment && t.noLineComments {
+				t.errorf(tok.pos, "line comment %q not allowed", tok.val)
+			}
+			comments = append(comments, t.newComment(Pos(tok.pos), tok.val))
+		case tokenModifier, tokenKeyword:
+			t.backup()
+			s.append(t.parseStatement(comments))
+			comments = nil
+		case tokenError:
+			t.errorf(tok.pos, "%s", tok.val)
+		default:
+			t.unexpected(tok, "section")
+		}
+	}
+}
+
+// appendComments adds comments not followed by a statement to the end of
+// the section.
+func appendComments(s *SectionNode, comments []*CommentNode) {
+	for _, c := range comments {
+		c.Attachment = CommentTrailing
+		s.append(c)
+	}
+}
+
+// parseStatement parses a single statement, optionally prefixed with a
+// modifier, which is either a leaf terminated by an end of statement or a
+// section. comments are attached to the statement as its leading comments.
+func (t *Tree) parseStatement(comments []*CommentNode) (n Node) {
+	kw := t.next()
+	pos := Pos(kw.pos)
+	start := kw.pos
+	if len(comments) > 0 {
+		start = int(comments[0].Pos)
+	}
+	defer func() {
+		if n != nil {
+			t.setSource(n, start)
+		}
+	}()
+	var modifier string
+	if kw.typ == tokenModifier {
+		modifier = kw.val
+		if kw = t.next(); kw.typ != tokenKeyword {
+			t.errorf(int(pos), "modifier %q must be followed by a statement", modifier)
+		}
+	}
+	var values []string
+	for {
+		switch tok := t.next(); tok.typ {
+		case tokenValue, tokenNumber:
+			values = append(values, tok.val)
+		case tokenListStart:
+			if len(values) > 0 {
+				t.errorf(tok.pos, "list must directly follow keyword %q", kw.val)
+			}
+			v := t.newValue(pos, kw.val, t.parseList(tok.pos))
+			v.Comments = comments
+			v.Modifier = modifier
+			v.List = true
+			return v
+		case tokenEndStatement:
+			v := t.newValue(pos, kw.val, values)
+			v.Comments = comments
+			v.Modifier = modifier
+			return v
+		case tokenSectionStart:
+			s := t.newSection(pos, kw.val, values)
+			s.Comments = comments
+			s.Modifier = modifier
+			t.parseSection(s)
+			return s
+		case tokenListEnd:
+			t.errorf(tok.pos, "unexpected ']'")
+		case tokenError:
+			t.errorf(tok.pos, "%s", tok.val)
+		default:
+			t.unexpected(tok, "statement")
+		}
+	}
+}
+
+// parseList parses the values of a list, which was opened by the '[' at
+// pos, up to and including the closing ']' and the end of the statement
+// that must follow it. A list left open is reported at the '['.
+func (t *Tree) parseList(pos int) []string {
+	values := []string{}
+	for {
+		switch tok := t.next(); tok.typ {
+		case tokenValue, tokenNumber:
+			values = append(values, tok.val)
+		case tokenListEnd:
+			if tok = t.next(); tok.typ != tokenEndStatement {
+				t.unexpected(tok, "list")
+			}
+			return values
+		case tokenEndStatement, tokenSectionStart, tokenSectionEnd, tokenEOF:
+			t.errorf(pos, "unclosed list, expected ']'")
+		case tokenError:
+			t.errorf(tok.pos, "%s", tok.val)
+		default:
+			t.unexpected(tok, "list")
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package jcfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const factoryConfig = `system {
+    syslog {
+        file messages {
+            any notice;
+            authorization info;
+        }
+        file interactive-commands {
+            interactive-commands any;
+        }
+        user "*" {
+            any emergency;
+        }
+    }
+}
+`
+
+type parseTest struct {
+	name   string
+	input  string
+	ok     bool
+	result string // what String() should produce
+}
+
+var parseTests = []parseTest{
+	{"empty", "", true, ""},
+	{"flag", "keyword;", true, "keyword;\n"},
+	{"values", "keyword value1 value2;", true, "keyword value1 value2;\n"},
+	{"section", "section { keyword1 value1; }", true, "section {\n    keyword1 value1;\n}\n"},
+	{"named section", "file messages { any notice; }", true, "file messages {\n    any notice;\n}\n"},
+	{"comments", "# hash\nsection { // line\n keyword; /* block */ }", true,
+		"# hash\nsection {\n    // line\n    keyword;\n    /* block */\n}\n"},
+	{"two statements on a line", "keyword1 value1; keyword2 value2;", true, "keyword1 value1;\nkeyword2 value2;\n"},
+	{"three statements on a line", "keyword1 value1; keyword2; keyword3 value3", true,
+		"keyword1 value1;\nkeyword2;\nkeyword3 value3;\n"},
+	{"packed section", "section { keyword1; keyword2 value2; }", true, "section {\n    keyword1;\n    keyword2 value2;\n}\n"},
+	{"modifier section", "inactive: protocols { bgp; }", true, "inactive: protocols {\n    bgp;\n}\n"},
+	{"modifier leaf", "system { protect: host-name r1; }", true, "system {\n    protect: host-name r1;\n}\n"},
+	{"quoted keyword section", "commands { \"foo bar\" { baz; } }", true, "commands {\n    \"foo bar\" {\n        baz;\n    }\n}\n"},
+	{"quoted keyword leaf", "commands { \"show version\" brief; }", true, "commands {\n    \"show version\" brief;\n}\n"},
+	{"multi-line quote", "system { login { message \"Authorized use only.\n  Disconnect now\"; } }", true,
+		"system {\n    login {\n        message \"Authorized use only.\n  Disconnect now\";\n    }\n}\n"},
+	{"multi-token section", "interfaces { interface ge-0/0/0 unit 0 { family inet; } }", true,
+		"interfaces {\n    interface ge-0/0/0 unit 0 {\n        family inet;\n    }\n}\n"},
+	{"truncated leaf", "system { host-name r1; }\nversion 1.0", true, "system {\n    host-name r1;\n}\nversion 1.0;\n"},
+	{"empty section no space", "section{}", true, "section {\n}\n"},
+	{"whitespace only section", "section {\n\n \t\n}", true, "section {\n}\n"},
+	{"comment only section", "section {\n    # only\n}", true, "section {\n    # only\n}\n"},
+	{"statement at EOF", "section { }\nkeyword value1", true, "section {\n}\nkeyword value1;\n"},
+	{"ipv6 prefix keyword", "policy-options { prefix-list p { 2001:db8::/32; } }", true,
+		"policy-options {\n    prefix-list p {\n        2001:db8::/32;\n    }\n}\n"},
+	{"url value", "system { archival { archive-sites { ftp://host//var/tmp; } } }\nurl http://x/y;", true,
+		"system {\n    archival {\n        archive-sites {\n            ftp://host//var/tmp;\n        }\n    }\n}\nurl http://x/y;\n"},
+	{"list", "vlan { members [ a \"b c\" ]; }", true, "vlan {\n    members [ a \"b c\" ];\n}\n"},
+	{"empty list", "members [ ];", true, "members [ ];\n"},
+	{"list after value", "keyword value [ a ];", false, ""},
+	{"unclosed list", "members [ a;", false, ""},
+	{"list section", "members [ a ] { b; }", false, ""},
+	{"unclosed section", "section { keyword;", false, ""},
+	{"extra close", "keyword; }", false, ""},
+	{"lex error", "keyword; ?", false, ""},
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range parseTests {
+		tree, err := Parse(test.name, test.input)
+		switch {
+		case err == nil && !test.ok:
+			t.Errorf("%q: expected error; got none", test.name)
+			continue
+		case err != nil && test.ok:
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		case err != nil && !test.ok:
+			t.Logf("%q: got expected error: %v", test.name, err)
+			continue
+		}
+		if result := tree.String(); result != test.result {
+			t.Errorf("%s=(%q): got\n\t%q\nexpected\n\t%q", test.name, test.input, result, test.result)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("got\n%s\nexpected\n%s", result, factoryConfig)
+	}
+}
+
+func TestParseFileGzip(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("got\n%s\nexpected\n%s", result, factoryConfig)
+	}
+}
+
+func TestParseBannerComments(t *testing.T) {
+	const input = `/* ===== SYSTEM ===== */
+system {
+    host-name r1;
+}
+/* ===== INTERFACES ===== */
+# managed by automation
+interfaces {
+    ge-0/0/0 {
+        description uplink;
+    }
+    /* trailing */
+}
+/* end of config */
+`
+	tree, err := Parse("banner", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		keyword  string
+		comments []string
+	}{
+		{"system", []string{"/* ===== SYSTEM ===== */"}},
+		{"interfaces", []string{"/* ===== INTERFACES ===== */", "# managed by automation"}},
+	}
+	stmts := statements(tree.Root.Nodes)
+	if len(stmts) != len(expected) {
+		t.Fatalf("got %d top-level statements, expected %d", len(stmts), len(expected))
+	}
+	for i, e := range expected {
+		s := stmts[i].(*SectionNode)
+		var comments []string
+		for _, c := range s.Comments {
+			comments = append(comments, c.Text)
+		}
+		if s.Keyword != e.keyword || !reflect.DeepEqual(comments, e.comments) {
+			t.Errorf("section %d: got %s with comments %q, expected %s with comments %q", i, s.Keyword, comments, e.keyword, e.comments)
+		}
+	}
+
+	if result := tree.String(); result != input {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, input)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	const input = `## Last changed: 2024-01-01 10:00:00 UTC
+# plain comment
+system {
+    ## managed by automation
+    host-name r1;
+    # single hash
+    domain-name example.com;
+    ### banner ###
+    ## two lines
+    time-zone UTC;
+}
+`
+	tree, err := Parse("annotations", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type comment struct {
+		text       string
+		attachment CommentAttachment
+	}
+	expected := map[string][]comment{
+		"system": {
+			{"## Last changed: 2024-01-01 10:00:00 UTC", CommentAnnotation},
+			{"# plain comment", CommentLeading},
+		},
+		"host-name":   {{"## managed by automation", CommentAnnotation}},
+		"domain-name": {{"# single hash", CommentLeading}},
+		"time-zone": {
+			{"### banner ###", CommentLeading},
+			{"## two lines", CommentAnnotation},
+		},
+	}
+	got := make(map[string][]comment)
+	Walk(tree.Root, func(path []string, n Node) bool {
+		var comments []*CommentNode
+		switch n := n.(type) {
+		case *ValueNode:
+			comments = n.Comments
+		case *SectionNode:
+			comments = n.Comments
+		}
+		for _, c := range comments {
+			got[path[len(path)-1]] = append(got[path[len(path)-1]], comment{c.Text, c.Attachment})
+		}
+		return true
+	})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", got, expected)
+	}
+
+	if result := tree.String(); result != input {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, input)
+	}
+}
+
+func TestParseLineComments(t *testing.T) {
+	const input = "system {\n    host-name r1;\n}\n// foo\nversion 1;\n"
+	for _, test := range []struct {
+		name string
+		opts ParseOptions
+		err  string
+	}{
+		{"strict", ParseOptions{}, `line comments:4:1: line comment "// foo" not allowed`},
+		{"lenient", ParseOptions{AllowLineComments: true}, ""},
+	} {
+		tree, err := ParseWithOptions("line comments", input, test.opts)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error\n\t%v\nexpected\n\t%v", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if result := tree.String(); result != input {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, result, input)
+		}
+	}
+	if _, err := Parse("line comments", input); err != nil {
+		t.Errorf("Parse: unexpected error: %v", err)
+	}
+	if _, err := ParseWithOptions("line comments", "# hash\n/* block */\nversion 1;\n", ParseOptions{}); err != nil {
+		t.Errorf("strict: unexpected error for Junos comments: %v", err)
+	}
+}
+
+func TestParseRuneColumns(t *testing.T) {
+	const input = "system {\n\thost-name r1;\n    location \"Zürich\"; ?\n}\n"
+	for _, test := range []struct {
+		name   string
+		opts   ParseOptions
+		col    int // column of host-name
+		errCol int // column of the '?'
+	}{
+		{"bytes", ParseOptions{}, 2, 25},
+		{"runes", ParseOptions{RuneColumns: true}, 2, 24},
+		{"tabs", ParseOptions{RuneColumns: true, TabWidth: 8}, 9, 24},
+	} {
+		_, err := ParseWithOptions("columns", input, test.opts)
+		serr, ok := err.(*SyntaxError)
+		if !ok || serr.Line != 3 || serr.Column != test.errCol {
+			t.Errorf("%s: got error %v, expected a *SyntaxError at 3:%d", test.name, err, test.errCol)
+		}
+		tree, err := ParseWithOptions("columns", strings.Replace(input, " ?", "", 1), test.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		n, _ := tree.Get("system", "host-name")
+		if line, col := n.(*ValueNode).LineCol(); line != 2 || col != test.col {
+			t.Errorf("%s: got host-name at %d:%d, expected 2:%d", test.name, line, col, test.col)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	const input = "disable;\nsystem {\n    services {\n        ssh;\n    }\n    no-redirects;\n}\n"
+	tree, err := Parse("flags", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range [][]string{
+		{"disable"},
+		{"system", "services", "ssh"},
+		{"system", "no-redirects"},
+	} {
+		n, ok := tree.Get(path...)
+		if !ok {
+			t.Errorf("%q: not found", path)
+			continue
+		}
+		v, ok := n.(*ValueNode)
+		if !ok || len(v.Values) != 0 || v.List {
+			t.Errorf("%q: got %T %v, expected a flag", path, n, n)
+		}
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, input)
+	}
+}
+
+func TestParseModifier(t *testing.T) {
+	const input = "inactive: protocols {\n    bgp;\n}\n"
+	tree, err := Parse("modifier", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tree.Root.Nodes[0].(*SectionNode)
+	if s.Modifier != "inactive" || s.Keyword != "protocols" {
+		t.Errorf("got modifier %q keyword %q, expected modifier %q keyword %q", s.Modifier, s.Keyword, "inactive", "protocols")
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("round trip: got\n%s\nexpected\n%s", result, input)
+	}
+}
+
+func TestParseModifierRoundTrip(t *testing.T) {
+	for _, modifier := range []string{"active", "inactive", "protect", "unprotect", "replace", "delete"} {
+		input := modifier + ": system {\n    " + modifier + ": host-name r1;\n    " + modifier + ": members [ a b ];\n}\n"
+		tree, err := Parse(modifier, input)
+		if err != nil {
+			t.Errorf("%s: %v", modifier, err)
+			continue
+		}
+		s := tree.Root.Nodes[0].(*SectionNode)
+		if s.Modifier != modifier {
+			t.Errorf("%s: got section modifier %q", modifier, s.Modifier)
+		}
+		for _, n := range s.Nodes {
+			if v := n.(*ValueNode); v.Modifier != modifier {
+				t.Errorf("%s: got modifier %q on %s", modifier, v.Modifier, v.Keyword)
+			}
+		}
+		if result := tree.String(); result != input {
+			t.Errorf("%s: round trip: got\n%s\nexpected\n%s", modifier, result, input)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	tree, err := ParseReader("reader", iotest.OneByteReader(strings.NewReader(factoryConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := tree.String(); result != factoryConfig {
+		t.Errorf("got\n%s\nexpected\n%s", result, factoryConfig)
+	}
+
+	_, err = ParseReader("reader", iotest.OneByteReader(strings.NewReader("system {\n    host-name r1;\n}\n}\n")))
+	if err == nil || !strings.Contains(err.Error(), "reader:4:") {
+		t.Errorf("got error %v, expected an error on line 4", err)
+	}
+}
+
+func TestParseModifierWithoutStatement(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		err   string
+	}{
+		{"inactive:;", `modifier:1:1: modifier "inactive" must be followed by a statement`},
+		{"system {\n    inactive: ;\n}", `modifier:2:5: modifier "inactive" must be followed by a statement`},
+		{"system { replace: }", `modifier:1:10: modifier "replace" must be followed by a statement`},
+		{"replace:", `modifier:1:1: modifier "replace" must be followed by a statement`},
+		{"replace: ", `modifier:1:1: modifier "replace" must be followed by a statement`},
+	} {
+		_, err := Parse("modifier", test.input)
+		if err == nil {
+			t.Errorf("%q: expected error; got none", test.input)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("%q: got error\n\t%v\nexpected\n\t%s", test.input, err, test.err)
+		}
+	}
+}
+
+func TestParseBraceBalance(t *testing.T) {
+	for _, test := range []struct {
+		input        string
+		line, column int
+		msg          string
+	}{
+		{"system {\n    host-name r1;\n}\n}\n", 4, 1, "unexpected '}'"},
+		{"}", 1, 1, "unexpected '}'"},
+		{"system {\n    syslog {\n        user \"*\" { any emergency; }\n", 2, 5, "unexpected EOF, expected '}'"},
+		{"system {\n    host-name r1;\n", 1, 1, "unexpected EOF, expected '}'"},
+		{"vlan {\n    members a ];\n}\n", 2, 15, "unexpected ']'"},
+		{"vlan {\n    members [ a b;\n}\n", 2, 13, "unclosed list, expected ']'"},
+		{"vlan {\n    members [ a b\n}\n", 2, 13, "unclosed list, expected ']'"},
+		{"members [ a", 1, 9, "unclosed list, expected ']'"},
+		{"system { host-name foo", 1, 1, "unexpected EOF, expected '}'"},
+		{"system {\n    ports {", 2, 5, "unexpected EOF, expected '}'"},
+	} {
+		_, err := Parse("braces", test.input)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%q: got error %v, expected a *SyntaxError", test.input, err)
+			continue
+		}
+		if serr.Line != test.line || serr.Column != test.column || serr.Msg != test.msg {
+			t.Errorf("%q: got\n\t%d:%d: %s\nexpected\n\t%d:%d: %s", test.input,
+				serr.Line, serr.Column, serr.Msg, test.line, test.column, test.msg)
+		}
+	}
+}
+
+func TestLineCol(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// system, syslog, file messages
+	third := tree.Root.Nodes[0].(*SectionNode).Nodes[0].(*SectionNode).Nodes[0]
+	if line, col := tree.LineCol(third.Position()); line != 3 || col != 3 {
+		t.Errorf("%s: got %d:%d, expected 3:3", third.(*SectionNode).name(), line, col)
+	}
+	n, _ := tree.Get("system", "syslog", `user "*"`, "any")
+	if line, col := n.(*ValueNode).LineCol(); line != 11 || col != 7 {
+		t.Errorf("any emergency: got %d:%d, expected 11:7", line, col)
+	}
+	n, _ = tree.Get("system", "syslog")
+	if line, col := n.(*SectionNode).LineCol(); line != 2 || col != 5 {
+		t.Errorf("syslog: got %d:%d, expected 2:5", line, col)
+	}
+
+	tree, err = Parse("linecol", "system {\n    host-name r1;\n    inactive: ports { console; }\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		path      []string
+		line, col int
+	}{
+		{[]string{"system"}, 1, 1},
+		{[]string{"system", "host-name"}, 2, 5},
+		{[]string{"system", "ports"}, 3, 5},
+		{[]string{"system", "ports", "console"}, 3, 23},
+	} {
+		n, ok := tree.Get(test.path...)
+		if !ok {
+			t.Fatalf("%q not found", test.path)
+		}
+		if line, col := tree.LineCol(n.Position()); line != test.line || col != test.col {
+			t.Errorf("%q: got %d:%d, expected %d:%d", test.path, line, col, test.line, test.col)
+		}
+	}
+
+	if line, col := New("empty").LineCol(0); line != 0 || col != 0 {
+		t.Errorf("empty tree: got %d:%d, expected 0:0", line, col)
+	}
+
+	// Nodes that were not parsed have no position, even in a parsed tree.
+	if err := tree.Set([]string{"system", "services", "ssh"}); err != nil {
+		t.Fatal(err)
+	}
+	built, err := NewBuilder().Section("system").Leaf("host-name", "r1").End().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := ParseSet("set", []string{"set system host-name r1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range map[string]struct {
+		tree *Tree
+		path []string
+	}{
+		"Set section": {tree, []string{"system", "services"}},
+		"Set leaf":    {tree, []string{"system", "services", "ssh"}},
+		"Builder":     {built, []string{"system", "host-name"}},
+		"ParseSet":    {set, []string{"system", "host-name"}},
+	} {
+		n, ok := test.tree.Get(test.path...)
+		if !ok {
+			t.Fatalf("%s: %q not found", name, test.path)
+		}
+		if line, col := n.(interface{ LineCol() (int, int) }).LineCol(); line != 0 || col != 0 {
+			t.Errorf("%s: got %d:%d, expected 0:0", name, line, col)
+		}
+	}
+}
+
+func TestParseErrorContext(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		err   string
+	}{
+		{"system {\n    host-name r1;\n}\ninterfaces ? ge-0/0/0 { mtu 9192; }\n",
+			`context:4:12: invalid character '?' in statement: "interfaces ? ge-0/0/0 { mtu 9192..."`},
+		{"system { ? }", `context:1:10: invalid character '?': "system { ? }"`},
+		{"a ?", `context:1:3: invalid character '?' in statement: "a ?"`},
+		// 20 bytes are shown on either side.
+		{"k " + strings.Repeat("a", 30) + "?" + strings.Repeat("b", 30) + ";",
+			`context:1:33: invalid character '?' in statement: "...` + strings.Repeat("a", 20) + "?" + strings.Repeat("b", 20) + `..."`},
+		{"description ☃ é;", `context:1:13: invalid character '☃' in statement: "description ☃ é;"`},
+	} {
+		_, err := Parse("context", test.input)
+		if err == nil {
+			t.Errorf("%q: expected error; got none", test.input)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("%q: got error\n\t%v\nexpected\n\t%s", test.input, err, test.err)
+		}
+		_, err = ParseReader("context", iotest.OneByteReader(strings.NewReader(test.input)))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: reader: got error\n\t%v\nexpected\n\t%s", test.input, err, test.err)
+		}
+	}
+}
+
+func TestParseLineEndings(t *testing.T) {
+	const input = "# managed\nsystem {\n    host-name r1 \n    domain-name example.com; // lab\n}\n"
+	expected, err := Parse("lf", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, eol := range []string{"\r\n", "\r"} {
+		tree, err := Parse("eol", strings.ReplaceAll(input, "\n", eol))
+		if err != nil {
+			t.Errorf("%q: %v", eol, err)
+			continue
+		}
+		if tree.String() != expected.String() {
+			t.Errorf("%q: got\n%s\nexpected\n%s", eol, tree, expected)
+		}
+		n, _ := tree.Get("system", "domain-name")
+		if line, col := tree.LineCol(n.Position()); line != 4 || col != 5 {
+			t.Errorf("%q: got domain-name at %d:%d, expected 4:5", eol, line, col)
+		}
+		_, err = Parse("eol", strings.ReplaceAll("system {\n    host-name r1;\n    ? \n}\n", "\n", eol))
+		if serr, ok := err.(*SyntaxError); !ok || serr.Line != 3 || serr.Column != 5 {
+			t.Errorf("%q: got error %v, expected a *SyntaxError at 3:5", eol, err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		input string
+	}{
+		{"factory", factoryConfig},
+		{"deep", deepConfig(10, 100)},
+		{"long line", strings.Repeat("keyword value; ", 40000)},
+		{"long line comments", strings.Repeat("/* note */ keyword value; ", 40000)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bm.input)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(bm.name, bm.input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
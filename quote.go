@@ -0,0 +1,83 @@
+package jcfg
+
+import (
+	"strings"
+)
+
+// Quote returns s as a configuration value, enclosing it in double quotes
+// when it is empty or contains anything other than the characters allowed
+// in a bare value. Double quotes, backslashes and newlines within a quoted
+// value are escaped.
+func Quote(s string) string {
+	if !needsQuote(s) {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// needsQuote reports whether s would not lex as a single bare value.
+func needsQuote(s string) bool {
+	if s == "" || strings.Contains(s, lineComment) {
+		return true
+	}
+	for _, r := range s {
+		if !isValueChar(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unquote returns the literal value of s. If s is enclosed in double
+// quotes they are removed and the escape sequences \", \\ and \n are
+// replaced with the character they stand for. Any other backslash is kept
+// as written. Values that are not quoted are returned unchanged.
+func Unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '"', '\\':
+			b.WriteByte(s[i+1])
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i+1])
+		}
+		i++
+	}
+	return b.String()
+}
+
+func unquoteValues(values []string) []string {
+	u := make([]string, len(values))
+	for i, v := range values {
+		u[i] = Unquote(v)
+	}
+	return u
+}
@@ -0,0 +1,57 @@
+package jcfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+type quoteTest struct {
+	name   string
+	quoted string
+	value  string
+}
+
+var quoteTests = []quoteTest{
+	{"bare", "value", "value"},
+	{"empty", `""`, ""},
+	{"spaces", `"hello world"`, "hello world"},
+	{"embedded quote", `"say \"hi\""`, `say "hi"`},
+	{"literal backslash", `"C:\\temp"`, `C:\temp`},
+	{"newline", `"line1\nline2"`, "line1\nline2"},
+	{"special characters", `"a;b{c}"`, "a;b{c}"},
+	{"wildcard", `"*"`, "*"},
+	{"address", "10.0.0.1/24", "10.0.0.1/24"},
+	{"comment start", `"a//b"`, "a//b"},
+}
+
+func TestQuote(t *testing.T) {
+	for _, test := range quoteTests {
+		if got := Unquote(test.quoted); got != test.value {
+			t.Errorf("%s: Unquote(%s) = %q, expected %q", test.name, test.quoted, got, test.value)
+		}
+		if got := Quote(test.value); got != test.quoted {
+			t.Errorf("%s: Quote(%q) = %s, expected %s", test.name, test.value, got, test.quoted)
+		}
+	}
+}
+
+func TestUnquoteUnknownEscape(t *testing.T) {
+	if got, expected := Unquote(`"^\d+$"`), `^\d+$`; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestValueNodeUnquoted(t *testing.T) {
+	const input = `message "say \"hi\" to C:\\temp" plain;`
+	tree, err := Parse("unquoted", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := tree.Root.Nodes[0].(*ValueNode)
+	if expected := []string{`say "hi" to C:\temp`, "plain"}; !reflect.DeepEqual(v.Unquoted(), expected) {
+		t.Errorf("got %q, expected %q", v.Unquoted(), expected)
+	}
+	if result := tree.String(); result != input+"\n" {
+		t.Errorf("quoted form not preserved: got %s, expected %s", result, input)
+	}
+}
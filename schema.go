@@ -0,0 +1,61 @@
+package jcfg
+
+// Schema describes statements a device assumes without them appearing in
+// the configuration.
+type Schema struct {
+	// Defaults are leaf statements in effect whenever they are absent.
+	Defaults []Default
+}
+
+// Default is a leaf statement applied when it is absent from its section.
+type Default struct {
+	// Path is the path to the leaf ending with its keyword, addressed as
+	// with Tree.Get. A "*" segment matches every section at that level.
+	Path   []string
+	Values []string
+}
+
+// WithDefaults returns a copy of the tree with the defaults in schema
+// added to every existing section their path matches that does not
+// already contain the leaf, producing the effective configuration.
+// Explicitly configured statements always override a default and no
+// sections are created to hold one. The original tree is not modified.
+func (t *Tree) WithDefaults(defaults *Schema) *Tree {
+	c := t.Clone()
+	for _, d := range defaults.Defaults {
+		if len(d.Path) == 0 {
+			continue
+		}
+		keyword := d.Path[len(d.Path)-1]
+		for _, s := range c.Root.matchSections(d.Path[:len(d.Path)-1]) {
+			if _, ok := s.child(keyword); ok {
+				continue
+			}
+			s.append(c.newValue(NoPos, keyword, append([]string(nil), d.Values...)))
+		}
+	}
+	return c
+}
+
+// matchSections returns the sections below s found by path where a "*"
+// segment matches all sections at that level.
+func (s *SectionNode) matchSections(path []string) []*SectionNode {
+	if len(path) == 0 {
+		return []*SectionNode{s}
+	}
+	var matches []*SectionNode
+	if path[0] == "*" {
+		for _, n := range s.Nodes {
+			if c, ok := n.(*SectionNode); ok {
+				matches = append(matches, c.matchSections(path[1:])...)
+			}
+		}
+		return matches
+	}
+	if n, ok := s.child(path[0]); ok {
+		if c, ok := n.(*SectionNode); ok {
+			matches = c.matchSections(path[1:])
+		}
+	}
+	return matches
+}
@@ -0,0 +1,43 @@
+package jcfg
+
+import (
+	"testing"
+)
+
+func TestWithDefaults(t *testing.T) {
+	const input = `interfaces {
+    ge-0/0/0 {
+        description uplink;
+    }
+    ge-0/0/1 {
+        mtu 9000;
+    }
+}
+`
+	const expected = `interfaces {
+    ge-0/0/0 {
+        description uplink;
+        mtu 1500;
+    }
+    ge-0/0/1 {
+        mtu 9000;
+    }
+}
+`
+	tree, err := Parse("defaults", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := &Schema{Defaults: []Default{
+		{Path: []string{"interfaces", "*", "mtu"}, Values: []string{"1500"}},
+		{Path: []string{"protocols", "lldp", "advertisement-interval"}, Values: []string{"30"}},
+	}}
+
+	effective := tree.WithDefaults(schema)
+	if result := effective.String(); result != expected {
+		t.Errorf("got\n%s\nexpected\n%s", result, expected)
+	}
+	if result := tree.String(); result != input {
+		t.Errorf("original tree modified: got\n%s\nexpected\n%s", result, input)
+	}
+}
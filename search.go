@@ -0,0 +1,73 @@
+package jcfg
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchRank orders how well a node matched a search query.
+type SearchRank int
+
+const (
+	RankSubstring SearchRank = iota + 1 // query occurs within a keyword or value
+	RankPrefix                          // a keyword or value starts with the query
+	RankExact                           // a keyword or value equals the query
+)
+
+// SearchResult is a single match returned by Search.
+type SearchResult struct {
+	Path []string // path to the node, ending with the node itself
+	Node Node
+	Rank SearchRank
+}
+
+// Search returns the statements whose keyword or one of its values
+// contains query, compared case-insensitively. Results are ordered by
+// rank, best first, and by their position in the configuration within
+// the same rank.
+func (t *Tree) Search(query string) []SearchResult {
+	query = strings.ToLower(query)
+	var results []SearchResult
+	Walk(t.Root, func(path []string, n Node) bool {
+		if len(path) == 0 {
+			return true
+		}
+		var rank SearchRank
+		switch n := n.(type) {
+		case *ValueNode:
+			rank = searchRank(query, n.Keyword, n.Values)
+		case *SectionNode:
+			rank = searchRank(query, n.Keyword, n.Values)
+		}
+		if rank > 0 {
+			results = append(results, SearchResult{Path: path, Node: n, Rank: rank})
+		}
+		return true
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	return results
+}
+
+// searchRank returns the best rank of query against the keyword and
+// values of a statement or 0 if none of them match.
+func searchRank(query, keyword string, values []string) SearchRank {
+	var best SearchRank
+	for _, w := range append([]string{keyword}, values...) {
+		w = strings.ToLower(Unquote(w))
+		var rank SearchRank
+		switch {
+		case w == query:
+			rank = RankExact
+		case strings.HasPrefix(w, query):
+			rank = RankPrefix
+		case strings.Contains(w, query):
+			rank = RankSubstring
+		}
+		if rank > best {
+			best = rank
+		}
+	}
+	return best
+}
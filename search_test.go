@@ -0,0 +1,64 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	const input = `system {
+    syslog {
+        file messages {
+            any notice;
+        }
+        remote-syslog-host 10.0.0.1;
+    }
+    services {
+        SYSLOG-relay;
+    }
+}
+syslog-archive enable;
+`
+	tree, err := Parse("search", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		path string
+		rank SearchRank
+	}{
+		{"system/syslog", RankExact},
+		{"system/services/SYSLOG-relay", RankPrefix},
+		{"syslog-archive", RankPrefix},
+		{"system/syslog/remote-syslog-host", RankSubstring},
+	}
+	results := tree.Search("Syslog")
+	if len(results) != len(expected) {
+		t.Fatalf("got %d results, expected %d: %+v", len(results), len(expected), results)
+	}
+	for i, e := range expected {
+		r := results[i]
+		if path := strings.Join(r.Path, "/"); path != e.path || r.Rank != e.rank {
+			t.Errorf("result %d: got %s (rank %d), expected %s (rank %d)", i, path, r.Rank, e.path, e.rank)
+		}
+	}
+
+	if results := tree.Search("no-such-thing"); len(results) != 0 {
+		t.Errorf("expected no results, got %+v", results)
+	}
+}
+
+func TestSearchValues(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := tree.Search("MESSAGES")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, expected 1: %+v", len(results), results)
+	}
+	if path := strings.Join(results[0].Path, "/"); path != "system/syslog/file messages" || results[0].Rank != RankExact {
+		t.Errorf("got %s (rank %d), expected system/syslog/file messages (rank %d)", path, results[0].Rank, RankExact)
+	}
+}
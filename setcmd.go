@@ -0,0 +1,373 @@
+package jcfg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// setVerbs maps the commands found in "show | display set" output to the
+// modifier they apply. "set" applies no modifier.
+var setVerbs = map[string]string{
+	"set":        "",
+	"deactivate": "inactive",
+	"protect":    "protect",
+}
+
+// identifierKeywords are keywords whose following word names the section
+// rather than starting a new level of hierarchy, as in "file messages" or
+// "unit 0". Set commands carry no schema so ParseSet relies on this list
+// to rebuild named sections.
+var identifierKeywords = map[string]bool{
+	"as-path":          true,
+	"community":        true,
+	"family":           true,
+	"file":             true,
+	"filter":           true,
+	"group":            true,
+	"host":             true,
+	"interface":        true,
+	"neighbor":         true,
+	"policer":          true,
+	"policy":           true,
+	"policy-statement": true,
+	"prefix-list":      true,
+	"route":            true,
+	"rule":             true,
+	"rule-set":         true,
+	"scheduler":        true,
+	"scheduler-map":    true,
+	"term":             true,
+	"unit":             true,
+	"user":             true,
+	"vlan":             true,
+	"zone":             true,
+}
+
+// SetCommands returns the configuration as the "set" commands that would
+// recreate it, in the same form as "show configuration | display set".
+// Each leaf statement and each empty section produces one line. A leaf
+// with several values is written with its values as a list, as in
+// "set a b [ c d ]", since ParseSet would otherwise take all but the last
+// of them for sections. Inactive and protected statements are followed by
+// a "deactivate" or "protect" line. Comments are omitted.
+func (t *Tree) SetCommands() []string {
+	cmds := t.setCommands()
+	lines := make([]string, len(cmds))
+	for i, c := range cmds {
+		lines[i] = c.String()
+	}
+	return lines
+}
+
+// MarshalSetOptions controls how a Tree is written by MarshalSet.
+type MarshalSetOptions struct {
+	// Deterministic sorts the set commands by path instead of following
+	// the order of the configuration so that regenerated output diffs
+	// cleanly. The sections matched by DefaultOrderedSections, such as
+	// firewall filter terms, keep their order, as that is significant.
+	// All "deactivate" and "protect" commands, also sorted, come after
+	// the set commands so that the statements they refer to already exist
+	// when loaded.
+	Deterministic bool
+}
+
+// MarshalSet writes the configuration to w as set commands, one per line,
+// as returned by SetCommands.
+func (t *Tree) MarshalSet(w io.Writer, opts MarshalSetOptions) error {
+	cmds := t.setCommands()
+	if opts.Deterministic {
+		keys := make([][]string, len(cmds))
+		for i, c := range cmds {
+			keys[i] = sortKey(c.words)
+		}
+		sort.Stable(byKey{cmds, keys})
+	}
+	for _, c := range cmds {
+		if _, err := io.WriteString(w, c.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCommand is a single set style command.
+type setCommand struct {
+	verb  string
+	words []string // path to the statement followed by its values
+}
+
+func (c setCommand) String() string {
+	return c.verb + " " + strings.Join(c.words, " ")
+}
+
+func (t *Tree) setCommands() []setCommand {
+	var cmds []setCommand
+	// pending holds the modifier commands of the sections being walked,
+	// which follow the commands for everything below them.
+	var pending []setCommand
+	Walk(t.Root, func(path []string, n Node) bool {
+		if len(path) == 0 || n.Type() == NodeComment {
+			return true
+		}
+		for len(pending) > 0 && len(pending[len(pending)-1].words) >= len(path) {
+			cmds = append(cmds, pending[len(pending)-1])
+			pending = pending[:len(pending)-1]
+		}
+		switch n := n.(type) {
+		case *ValueNode:
+			values := n.Values
+			if n.List || len(values) > 1 {
+				values = listValues(values)
+			}
+			cmds = append(cmds, setCommand{"set", append(copyStrings(path), values...)})
+			if verb := modifierVerb(n.Modifier); verb != "" {
+				cmds = append(cmds, setCommand{verb, path})
+			}
+		case *SectionNode:
+			if len(statements(n.Nodes)) == 0 {
+				cmds = append(cmds, setCommand{"set", path})
+			}
+			if verb := modifierVerb(n.Modifier); verb != "" {
+				pending = append(pending, setCommand{verb, path})
+			}
+		}
+		return true
+	})
+	for i := len(pending) - 1; i >= 0; i-- {
+		cmds = append(cmds, pending[i])
+	}
+	return cmds
+}
+
+// sortKey returns the part of the words of a command that it is sorted
+// by: up to the section holding the first ordered section in them, so
+// that the commands for ordered sections stay in their original order.
+func sortKey(words []string) []string {
+	for i := 1; i <= len(words); i++ {
+		if orderedSection(nil, words[:i]) {
+			return words[:i-1]
+		}
+	}
+	return words
+}
+
+// byKey sorts set commands by verb and then by their sort keys.
+type byKey struct {
+	cmds []setCommand
+	keys [][]string
+}
+
+func (b byKey) Len() int { return len(b.cmds) }
+
+func (b byKey) Less(i, j int) bool {
+	if (b.cmds[i].verb == "set") != (b.cmds[j].verb == "set") {
+		return b.cmds[i].verb == "set"
+	}
+	return lessPath(b.keys[i], b.keys[j])
+}
+
+func (b byKey) Swap(i, j int) {
+	b.cmds[i], b.cmds[j] = b.cmds[j], b.cmds[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// lessPath reports whether path a sorts before path b, comparing one
+// segment at a time.
+func lessPath(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// modifierVerb returns the command that applies modifier, or "" if it
+// has none.
+func modifierVerb(modifier string) string {
+	for verb, m := range setVerbs {
+		if m != "" && m == modifier {
+			return verb
+		}
+	}
+	return ""
+}
+
+// ParseSet builds a Tree from "set" commands such as the output of
+// "show configuration | display set". Lines sharing a prefix are merged
+// into shared sections. Values may be quoted and a leaf may be given a
+// list of values as in "set a b [ c d ]". "deactivate" and "protect"
+// lines mark the statement at their path with the matching modifier.
+// Blank lines and lines starting with '#' are skipped.
+//
+// Set commands do not say where a section ends and a leaf begins, so a
+// keyword followed by exactly one final word becomes a leaf with that
+// value, and any other keyword becomes a section. Keywords known to name
+// their sections, such as "file" and "unit", take the following word as
+// the section's identifier. An empty section is therefore recreated as a
+// flag and a leaf with several values only round-trips when written as a
+// list, as SetCommands does.
+func ParseSet(name string, lines []string) (*Tree, error) {
+	root := &setNode{}
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, hashComment) {
+			continue
+		}
+		words, list, err := splitSetLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, i+1, err)
+		}
+		modifier, ok := setVerbs[words[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown command %q", name, i+1, words[0])
+		}
+		if len(words) == 1 {
+			return nil, fmt.Errorf("%s:%d: %s without a statement", name, i+1, words[0])
+		}
+		n := root.insert(words[1:])
+		if modifier != "" {
+			n.modifier = modifier
+		}
+		if list != nil {
+			n.list = true
+			n.values = list
+		}
+	}
+	t := New(name)
+	for _, c := range root.children {
+		t.Root.append(c.node(t))
+	}
+	return t, nil
+}
+
+// setNode is a level of hierarchy built up from set commands before it is
+// converted into Nodes.
+type setNode struct {
+	keyword  string
+	id       string // identifier following an identifier keyword
+	modifier string
+	list     bool
+	values   []string
+	children []*setNode
+}
+
+// insert adds the path of words below n, reusing existing children, and
+// returns the final node.
+func (n *setNode) insert(words []string) *setNode {
+	for len(words) > 0 {
+		kw, id := words[0], ""
+		words = words[1:]
+		if identifierKeywords[kw] && len(words) > 0 {
+			id, words = words[0], words[1:]
+		}
+		n = n.child(kw, id)
+	}
+	return n
+}
+
+func (n *setNode) child(keyword, id string) *setNode {
+	for _, c := range n.children {
+		if c.keyword == keyword && c.id == id {
+			return c
+		}
+	}
+	c := &setNode{keyword: keyword, id: id}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *setNode) node(t *Tree) Node {
+	var ids []string
+	if n.id != "" {
+		ids = []string{n.id}
+	}
+	switch {
+	case n.list:
+		v := t.newValue(NoPos, n.keyword, n.values)
+		v.Modifier = n.modifier
+		v.List = true
+		return v
+	case len(n.children) == 0:
+		v := t.newValue(NoPos, n.keyword, ids)
+		v.Modifier = n.modifier
+		return v
+	case n.id == "" && len(n.children) == 1 && n.children[0].isValue():
+		v := t.newValue(NoPos, n.keyword, []string{n.children[0].keyword})
+		v.Modifier = n.modifier
+		return v
+	}
+	s := t.newSection(NoPos, n.keyword, ids)
+	s.Modifier = n.modifier
+	for _, c := range n.children {
+		s.append(c.node(t))
+	}
+	return s
+}
+
+// isValue reports whether n can only be the final value of a leaf.
+func (n *setNode) isValue() bool {
+	return n.id == "" && n.modifier == "" && !n.list && len(n.children) == 0
+}
+
+// splitSetLine splits a set command into words, keeping quoted strings
+// intact. A trailing "[ ... ]" list is returned separately.
+func splitSetLine(line string) (words, list []string, err error) {
+	inList := false
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '[':
+			if inList || len(words) == 0 {
+				return nil, nil, fmt.Errorf("unexpected '['")
+			}
+			inList = true
+			list = []string{}
+			i++
+		case c == ']':
+			if !inList || strings.TrimSpace(line[i+1:]) != "" {
+				return nil, nil, fmt.Errorf("unexpected ']'")
+			}
+			return words, list, nil
+		default:
+			end := i
+			if c == '"' {
+				end = quoteEnd(line, i)
+				if end < 0 {
+					return nil, nil, fmt.Errorf("unterminated quoted string")
+				}
+			} else {
+				for end < len(line) && line[end] != ' ' && line[end] != '\t' && line[end] != ']' {
+					end++
+				}
+			}
+			if inList {
+				list = append(list, line[i:end])
+			} else {
+				words = append(words, line[i:end])
+			}
+			i = end
+		}
+	}
+	if inList {
+		return nil, nil, fmt.Errorf("unterminated list")
+	}
+	return words, nil, nil
+}
+
+// quoteEnd returns the index just past the closing quote of the quoted
+// string starting at s[i], or -1 if it is unterminated.
+func quoteEnd(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,244 @@
+package jcfg
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetCommands(t *testing.T) {
+	const input = `system {
+    host-name r1;
+    inactive: ports {
+        console insecure;
+    }
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+    login {
+        message "hello world";
+    }
+    empty { }
+}
+policy-options {
+    policy-statement p {
+        from {
+            route-filter 10.0.0.0/8 exact;
+        }
+    }
+}
+`
+	tree, err := Parse("setcommands", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"set system host-name r1",
+		"set system ports console insecure",
+		"deactivate system ports",
+		"set system syslog file messages any notice",
+		`set system login message "hello world"`,
+		"set system empty",
+		"set policy-options policy-statement p from route-filter [ 10.0.0.0/8 exact ]",
+	}
+	if lines := tree.SetCommands(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", lines, expected)
+	}
+}
+
+func TestParseSetRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		factoryConfig,
+		"interfaces { ge-0/0/0 { unit 0 { family inet { address 10.0.0.1/24; } } } }",
+		"system { host-name r1; inactive: ports { console insecure; } }",
+		`system { login { message "hello world"; } }`,
+		"policy-options { community C { members [ 65000:1 65000:2 ]; } }",
+		"match foo bar;",
+		"firewall { filter f { term t { from { source-address { 10.0.0.0/8 except; } } } } }",
+	} {
+		tree, err := Parse("curly", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := tree.SetCommands()
+		set, err := ParseSet("set", lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tree.EqualWithOptions(set, EqualOptions{Ordered: true}) {
+			t.Errorf("round trip through\n\t%q\ngot\n%s\nexpected\n%s", lines, set, tree)
+		}
+	}
+}
+
+type parseSetTest struct {
+	name   string
+	lines  []string
+	ok     bool
+	result string
+}
+
+var parseSetTests = []parseSetTest{
+	{"merge prefixes", []string{
+		"set system host-name r1",
+		"",
+		"# comment",
+		"set system syslog file messages any notice",
+		"set system syslog file messages authorization info",
+	}, true, "system {\n    host-name r1;\n    syslog {\n        file messages {\n            any notice;\n            authorization info;\n        }\n    }\n}\n"},
+	{"single word leaf", []string{"set system ports console"}, true, "system {\n    ports console;\n}\n"},
+	{"flags", []string{"set system ports console", "set system ports auxiliary"}, true,
+		"system {\n    ports {\n        console;\n        auxiliary;\n    }\n}\n"},
+	{"list", []string{"set policy-options community C members [ 65000:1 65000:2 ]"}, true,
+		"policy-options {\n    community C {\n        members [ 65000:1 65000:2 ];\n    }\n}\n"},
+	{"quoted value", []string{`set system login message "hello \"big\" world"`}, true,
+		"system {\n    login {\n        message \"hello \\\"big\\\" world\";\n    }\n}\n"},
+	{"protect", []string{"set system host-name r1", "protect system host-name"}, true,
+		"system {\n    protect: host-name r1;\n}\n"},
+	{"unknown command", []string{"delete system host-name"}, false, ""},
+	{"missing statement", []string{"set"}, false, ""},
+	{"unterminated quote", []string{`set system login message "hello`}, false, ""},
+	{"unterminated list", []string{"set a members [ b c"}, false, ""},
+	{"stray list end", []string{"set a members b ]"}, false, ""},
+}
+
+func TestParseSet(t *testing.T) {
+	for _, test := range parseSetTests {
+		tree, err := ParseSet(test.name, test.lines)
+		switch {
+		case err == nil && !test.ok:
+			t.Errorf("%q: expected error; got none", test.name)
+			continue
+		case err != nil && test.ok:
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		case err != nil && !test.ok:
+			t.Logf("%q: got expected error: %v", test.name, err)
+			continue
+		}
+		if result := tree.String(); result != test.result {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, result, test.result)
+		}
+	}
+}
+
+func TestMarshalSetDeterministic(t *testing.T) {
+	const input = `system {
+    syslog {
+        user "*" {
+            any emergency;
+        }
+        file messages {
+            any notice;
+        }
+    }
+    inactive: ports {
+        console insecure;
+    }
+    host-name r1;
+}
+interfaces {
+    ge-0/0/1 {
+        mtu 9000;
+    }
+    ge-0/0/0 {
+        description uplink;
+    }
+}
+`
+	const expected = `set interfaces ge-0/0/0 description uplink
+set interfaces ge-0/0/1 mtu 9000
+set system host-name r1
+set system ports console insecure
+set system syslog file messages any notice
+set system syslog user "*" any emergency
+deactivate system ports
+`
+	tree, err := Parse("deterministic", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second bytes.Buffer
+	if err := tree.MarshalSet(&first, MarshalSetOptions{Deterministic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.MarshalSet(&second, MarshalSetOptions{Deterministic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", first.String(), expected)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("output differs between runs:\n%s\n%s", first.String(), second.String())
+	}
+
+	var ordered bytes.Buffer
+	if err := tree.MarshalSet(&ordered, MarshalSetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Join(tree.SetCommands(), "\n") + "\n"; ordered.String() != lines {
+		t.Errorf("got\n%s\nexpected configuration order\n%s", ordered.String(), lines)
+	}
+}
+
+func TestMarshalSetDeterministicOrderedTerms(t *testing.T) {
+	const input = `firewall {
+    family inet {
+        filter f {
+            term z {
+                then accept;
+            }
+            term a {
+                from {
+                    protocol tcp;
+                }
+                then discard;
+            }
+            interface-specific;
+        }
+    }
+}
+policy-options {
+    policy-statement p {
+        term two {
+            then reject;
+        }
+        term one {
+            then accept;
+        }
+    }
+}
+`
+	const expected = `set firewall family inet filter f term z then accept
+set firewall family inet filter f term a from protocol tcp
+set firewall family inet filter f term a then discard
+set firewall family inet filter f interface-specific
+set policy-options policy-statement p term two then reject
+set policy-options policy-statement p term one then accept
+`
+	tree, err := Parse("terms", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tree.MarshalSet(&b, MarshalSetOptions{Deterministic: true}); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+	loaded, err := ParseSet("terms", lines(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(tree) {
+		t.Errorf("round trip not equal:\n%s", loaded)
+	}
+	if changes := Diff(tree, loaded); len(changes) != 0 {
+		t.Errorf("round trip changes: %+v", changes)
+	}
+}
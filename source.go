@@ -0,0 +1,137 @@
+package jcfg
+
+import (
+	"crypto/sha256"
+	"strings"
+)
+
+// source is the original text of a parsed statement, kept so that a
+// statement that has not been modified since can be written back exactly
+// as it appeared in the input.
+type source struct {
+	// text is the statement with its leading comments, any blank lines
+	// before them and, if nothing else follows on its last line, the line
+	// ending. It is empty for statements that were not parsed.
+	text string
+	// indented is true if text starts at the beginning of a line and so
+	// includes its own indentation.
+	indented bool
+}
+
+// nodeSource returns the source of the statement n, or nil for a comment.
+func nodeSource(n Node) *source {
+	switch n := n.(type) {
+	case *ValueNode:
+		return &n.src
+	case *SectionNode:
+		return &n.src
+	}
+	return nil
+}
+
+// setSource records the original text of the statement n, which started
+// at start in input and whose last token has just been read.
+func (t *Tree) setSource(n Node, start int) {
+	input := t.lex.text()
+	end := t.token.pos + len(t.token.val)
+	if end > len(input) {
+		end = len(input)
+	}
+
+	// Take in the indentation and blank lines before the statement.
+	indented := false
+	if lineStart := t.lex.lineStart(start); isIndentation(input, lineStart, start) {
+		start, indented = lineStart, true
+		for start > 0 {
+			prev := t.lex.lineStart(start - 1)
+			if strings.TrimSpace(input[prev:start]) != "" {
+				break
+			}
+			start = prev
+		}
+	}
+
+	// Take in the rest of the line if there is nothing else on it. The
+	// lexer may not have read that far into a streaming input yet.
+	if end == 0 || !isEndOfLine(rune(input[end-1])) {
+		rest := end
+		for t.lex.available(rest) && (t.lex.input[rest] == ' ' || t.lex.input[rest] == '\t') {
+			rest++
+		}
+		input = t.lex.text()
+		switch {
+		case rest == len(input):
+			end = rest
+		case isEndOfLine(rune(input[rest])):
+			end = rest + 1
+		}
+	}
+	if end > 0 && input[end-1] == '\r' && t.lex.available(end) && t.lex.input[end] == '\n' {
+		end++
+	}
+
+	src := nodeSource(n)
+	src.text = t.lex.text()[start:end]
+	src.indented = indented
+}
+
+// isIndentation reports whether input between lineStart, the start of a
+// line, and pos is only spaces and tabs. It looks back from pos so that
+// it stops at the first other character however long the line is.
+func isIndentation(input string, lineStart, pos int) bool {
+	for pos > lineStart && (input[pos-1] == ' ' || input[pos-1] == '\t') {
+		pos--
+	}
+	return pos == lineStart
+}
+
+// parsedSum returns the digest of the statement n as it was parsed, or
+// false if it was not parsed. Rather than being kept for every statement
+// the digest is worked out when needed by parsing the source text again.
+func parsedSum(n Node) ([32]byte, bool) {
+	src := nodeSource(n)
+	if src == nil || src.text == "" {
+		return [32]byte{}, false
+	}
+	t, err := Parse("source", src.text)
+	if err != nil || len(statements(t.Root.Nodes)) != 1 {
+		return [32]byte{}, false
+	}
+	var sum func(Node) [32]byte
+	sum = func(n Node) [32]byte { return digest(n, sum) }
+	return sum(statements(t.Root.Nodes)[0]), true
+}
+
+// digest returns a digest of everything printed for the statement n,
+// using sum for each statement directly below it.
+func digest(n Node, sum func(Node) [32]byte) [32]byte {
+	h := sha256.New()
+	var comments []*CommentNode
+	switch n := n.(type) {
+	case *ValueNode:
+		hashStrings(h, "leaf", n.Modifier, n.Keyword)
+		if n.List {
+			hashStrings(h, "list")
+		}
+		hashStrings(h, n.Values...)
+		comments = n.Comments
+	case *SectionNode:
+		hashStrings(h, "section", n.Modifier, n.Keyword)
+		hashStrings(h, n.Values...)
+		for _, c := range n.Nodes {
+			if c, ok := c.(*CommentNode); ok {
+				hashStrings(h, "comment", c.Text)
+				continue
+			}
+			s := sum(c)
+			h.Write(s[:])
+		}
+		comments = n.Comments
+	}
+	for _, c := range comments {
+		hashStrings(h, "leading", c.Text)
+	}
+	var s [32]byte
+	h.Sum(s[:0])
+	return s
+}
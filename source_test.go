@@ -0,0 +1,85 @@
+package jcfg
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const preserveConfig = `## Last commit: 2024-01-01 10:00:00 UTC
+system {
+	host-name   r1;
+
+	/* name servers */
+	name-server [ 192.0.2.1  192.0.2.2 ];
+	syslog {
+	    file messages { any notice; }
+	}
+}
+
+interfaces {
+    ge-0/0/0 {
+        unit 0 {
+            family inet {
+                address 10.0.0.1/24;   # uplink
+            }
+        }
+    }
+}
+`
+
+func TestPreserveUnmodified(t *testing.T) {
+	tree, err := Parse("preserve", preserveConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Format(PrintOptions{Preserve: true}); got != preserveConfig {
+		t.Errorf("got\n%s\nexpected\n%s", got, preserveConfig)
+	}
+}
+
+func TestPreserveEdit(t *testing.T) {
+	tree, err := Parse("preserve", preserveConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set([]string{"system", "host-name"}, "r2"); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(tree.Format(PrintOptions{Preserve: true}), "\n")
+	orig := strings.Split(preserveConfig, "\n")
+	if len(got) != len(orig) {
+		t.Fatalf("got %d lines, expected %d:\n%s", len(got), len(orig), strings.Join(got, "\n"))
+	}
+	var changed []string
+	for i := range orig {
+		if got[i] != orig[i] {
+			changed = append(changed, got[i])
+		}
+	}
+	if len(changed) != 1 || changed[0] != "    host-name r2;" {
+		t.Errorf("got changed lines\n\t%q\nexpected\n\t%q", changed, []string{"    host-name r2;"})
+	}
+}
+
+func TestPreserveReader(t *testing.T) {
+	for _, input := range []string{
+		preserveConfig,
+		"a;   b",
+		"a;   \nb;\r\n",
+		"system {\n    host-name r1;    \n}\n",
+	} {
+		parsed, err := Parse("preserve", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, err := ParseReader("preserve", iotest.OneByteReader(strings.NewReader(input)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := parsed.Format(PrintOptions{Preserve: true})
+		if got := read.Format(PrintOptions{Preserve: true}); got != expected {
+			t.Errorf("%q: got\n%q\nexpected as parsed\n%q", input, got, expected)
+		}
+	}
+}
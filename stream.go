@@ -0,0 +1,240 @@
+package jcfg
+
+import (
+	"io"
+)
+
+// A Decoder reads the top-level statements of a configuration from an
+// input stream one at a time. Only as much input is read as is needed
+// for the statement being returned, so a caller interested in part of a
+// large configuration can stop once it has found it.
+type Decoder struct {
+	t        *Tree
+	comments []*CommentNode // comments awaiting the next statement
+	err      error          // sticky error, io.EOF at the end of input
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	t := New("")
+	t.startParse(lexReader("", r))
+	return &Decoder{t: t}
+}
+
+// Token returns the next top-level statement, a *ValueNode or
+// *SectionNode, with any comments preceding it attached. Comments at the
+// end of the input that are not followed by a statement are returned as
+// *CommentNode. At the end of the input Token returns io.EOF. Invalid
+// input returns a *SyntaxError. Once Token has returned an error every
+// further call returns the same error.
+func (d *Decoder) Token() (n Node, err error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	defer func() {
+		if err != nil {
+			d.err = err
+		}
+	}()
+	defer d.t.recover(&err)
+	for {
+		switch tok := d.t.next(); tok.typ {
+		case tokenEOF:
+			if len(d.comments) > 0 {
+				c := d.comments[0]
+				d.comments = d.comments[1:]
+				c.Attachment = CommentTrailing
+				d.t.backup()
+				return c, nil
+			}
+			d.t.stopParse()
+			return nil, io.EOF
+		case tokenLineComment, tokenHashComment, tokenAnnotation, tokenBlockComment:
+			d.comments = append(d.comments, d.t.newComment(Pos(tok.pos), tok.val))
+		case tokenModifier, tokenKeyword:
+			d.t.backup()
+			n := d.t.parseStatement(d.comments)
+			d.comments = nil
+			return n, nil
+		case tokenSectionEnd:
+			d.t.errorf(tok.pos, "unexpected '}'")
+		case tokenError:
+			d.t.errorf(tok.pos, "%s", tok.val)
+		default:
+			d.t.unexpected(tok, "section")
+		}
+	}
+}
+
+// Close stops the decoder, after which Token returns io.EOF and More
+// returns false. It drops the decoder's reference to the reader and to any
+// input read ahead of the statements returned so far. Calling it is
+// optional; a decoder that is no longer referenced holds nothing that
+// outlives it.
+func (d *Decoder) Close() {
+	if d.err == nil {
+		d.t.stopParse()
+		d.err = io.EOF
+	}
+}
+
+// More reports whether there is anything left for Token to return other
+// than io.EOF. It returns true if the remaining input is invalid so that
+// Token can report the error.
+func (d *Decoder) More() bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.comments) > 0 {
+		return true
+	}
+	tok := d.t.next()
+	d.t.backup()
+	return tok.typ != tokenEOF
+}
+
+// An Encoder writes trees to an output stream in the Junos curly-brace
+// format. Each statement is written as it is reached rather than first
+// building the whole configuration in memory.
+type Encoder struct {
+	w    io.Writer
+	opts PrintOptions
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetIndent sets the string written for each level of nesting. The
+// default is the four spaces Junos uses.
+func (enc *Encoder) SetIndent(indent string) {
+	enc.opts.Indent = indent
+}
+
+// Encode writes t to the stream. It returns the first error from the
+// underlying writer, after which nothing more of t is written.
+func (enc *Encoder) Encode(t *Tree) error {
+	p := &printer{w: enc.w, opts: enc.opts}
+	for _, n := range t.Root.Nodes {
+		p.printNode(n, 0)
+	}
+	return p.err
+}
+
+// A Handler receives the statements and comments of a configuration from
+// ParseStream as they are read. Paths are as passed to a Walk function,
+// ending with the keyword of a leaf statement or the name of a section.
+// To avoid allocating, the same path slice is reused from one call to the
+// next, so a Handler must copy a path it wants to retain.
+type Handler interface {
+	// StartSection is called at the opening '{' of a section.
+	StartSection(path []string)
+	// EndSection is called at the closing '}' of a section.
+	EndSection(path []string)
+	// Statement is called for each leaf statement with its values, or
+	// the members of its list, exactly as written.
+	Statement(path []string, values []string)
+	// Comment is called for each comment with its text exactly as written.
+	Comment(text string)
+}
+
+// ParseStream parses the configuration in input calling h for each
+// section, leaf statement and comment in the order they appear, without
+// building a Tree. Modifiers such as "inactive:" are not reported. Invalid
+// input returns a *SyntaxError, after h has been called for everything
+// before the error.
+func ParseStream(name, input string, h Handler) (err error) {
+	t := New(name)
+	defer t.recover(&err)
+	t.startParse(lex(name, input))
+	p := &streamParser{t: t, h: h}
+	p.section(-1)
+	t.stopParse()
+	return nil
+}
+
+// streamParser parses a configuration into calls to a Handler using the
+// token stream and error handling of a Tree.
+type streamParser struct {
+	t    *Tree
+	h    Handler
+	path []string // path to the statement being parsed
+}
+
+// section parses the statements of the section whose keyword is at pos
+// up to and including the closing '}', or EOF for the top level, which
+// has a pos of -1.
+func (p *streamParser) section(pos int) {
+	for {
+		switch tok := p.t.next(); tok.typ {
+		case tokenEOF:
+			if pos >= 0 {
+				p.t.errorf(pos, "unexpected EOF, expected '}'")
+			}
+			return
+		case tokenSectionEnd:
+			if pos < 0 {
+				p.t.errorf(tok.pos, "unexpected '}'")
+			}
+			return
+		case tokenLineComment, tokenHashComment, tokenAnnotation, tokenBlockComment:
+			p.h.Comment(tok.val)
+		case tokenModifier, tokenKeyword:
+			p.t.backup()
+			p.statement()
+		case tokenError:
+			p.t.errorf(tok.pos, "%s", tok.val)
+		default:
+			p.t.unexpected(tok, "section")
+		}
+	}
+}
+
+// statement parses a single statement, as Tree.parseStatement does.
+func (p *streamParser) statement() {
+	kw := p.t.next()
+	if kw.typ == tokenModifier {
+		modifier := kw.val
+		pos := kw.pos
+		if kw = p.t.next(); kw.typ != tokenKeyword {
+			p.t.errorf(pos, "modifier %q must be followed by a statement", modifier)
+		}
+	}
+	var values []string
+	for {
+		switch tok := p.t.next(); tok.typ {
+		case tokenValue, tokenNumber:
+			values = append(values, tok.val)
+		case tokenListStart:
+			if len(values) > 0 {
+				p.t.errorf(tok.pos, "list must directly follow keyword %q", kw.val)
+			}
+			p.leaf(kw.val, p.t.parseList(tok.pos))
+			return
+		case tokenEndStatement:
+			p.leaf(kw.val, values)
+			return
+		case tokenSectionStart:
+			p.path = append(p.path, statement(kw.val, values))
+			p.h.StartSection(p.path)
+			p.section(kw.pos)
+			p.h.EndSection(p.path)
+			p.path = p.path[:len(p.path)-1]
+			return
+		case tokenListEnd:
+			p.t.errorf(tok.pos, "unexpected ']'")
+		case tokenError:
+			p.t.errorf(tok.pos, "%s", tok.val)
+		default:
+			p.t.unexpected(tok, "statement")
+		}
+	}
+}
+
+// leaf reports the leaf statement keyword with values to the handler.
+func (p *streamParser) leaf(keyword string, values []string) {
+	p.path = append(p.path, keyword)
+	p.h.Statement(p.path, values)
+	p.path = p.path[:len(p.path)-1]
+}
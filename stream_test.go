@@ -0,0 +1,219 @@
+package jcfg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncoder(t *testing.T) {
+	tree, err := Parse("encoder", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	enc := NewEncoder(&b)
+	if err := enc.Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != factoryConfig {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), factoryConfig)
+	}
+
+	b.Reset()
+	enc.SetIndent("\t")
+	if err := enc.Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	if expected := tree.Format(PrintOptions{Indent: "\t"}); b.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b.String(), expected)
+	}
+}
+
+// failWriter accepts n writes and fails every one after that.
+type failWriter struct {
+	n     int
+	calls int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls > w.n {
+		return 0, errWrite
+	}
+	return len(p), nil
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	tree, err := Parse("encoder", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &failWriter{n: 2}
+	if err := NewEncoder(w).Encode(tree); err != errWrite {
+		t.Errorf("got error %v, expected %v", err, errWrite)
+	}
+	if w.calls != 3 {
+		t.Errorf("got %d writes, expected 3", w.calls)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	const input = `# the system
+system {
+    host-name r1;
+}
+interfaces {
+    ge-0/0/0 {
+        mtu 9192;
+    }
+}
+version 1.0;
+/* end */
+`
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader(input)))
+	var got []string
+	for dec.More() {
+		n, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%T %s", n, firstLine(n.String())))
+	}
+	expected := []string{
+		"*jcfg.SectionNode # the system",
+		"*jcfg.SectionNode interfaces {",
+		"*jcfg.ValueNode version 1.0;",
+		"*jcfg.CommentNode /* end */",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+	for i := 0; i < 2; i++ {
+		if n, err := dec.Token(); err != io.EOF {
+			t.Errorf("got %v, %v at the end of input, expected io.EOF", n, err)
+		}
+	}
+}
+
+func TestDecoderSyntaxError(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("system { host-name r1; }\n}\nsnmp;\n"))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if !dec.More() {
+		t.Fatal("More returned false before the syntax error")
+	}
+	_, err := dec.Token()
+	serr, ok := err.(*SyntaxError)
+	if !ok || serr.Line != 2 || serr.Column != 1 {
+		t.Fatalf("got error %v, expected a *SyntaxError at 2:1", err)
+	}
+	if dec.More() {
+		t.Error("More returned true after the syntax error")
+	}
+	if _, again := dec.Token(); again != err {
+		t.Errorf("got error %v after the syntax error, expected %v", again, err)
+	}
+}
+
+func TestDecoderClose(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(factoryConfig + factoryConfig))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	dec.Close()
+	if _, err := dec.Token(); err != io.EOF {
+		t.Fatalf("got %v after Close, expected io.EOF", err)
+	}
+	if dec.More() {
+		t.Error("More returned true after Close")
+	}
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
+
+// eventHandler records the calls made by ParseStream.
+type eventHandler struct {
+	sections int
+	events   []string
+}
+
+func (h *eventHandler) StartSection(path []string) {
+	h.sections++
+	h.events = append(h.events, "start "+strings.Join(path, "/"))
+}
+
+func (h *eventHandler) EndSection(path []string) {
+	h.events = append(h.events, "end "+strings.Join(path, "/"))
+}
+
+func (h *eventHandler) Statement(path []string, values []string) {
+	h.events = append(h.events, fmt.Sprintf("statement %s %q", strings.Join(path, "/"), values))
+}
+
+func (h *eventHandler) Comment(text string) {
+	h.events = append(h.events, "comment "+text)
+}
+
+func TestParseStream(t *testing.T) {
+	h := &eventHandler{}
+	if err := ParseStream("stream", factoryConfig, h); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Parse("stream", factoryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := 0
+	Walk(tree.Root, func(path []string, n Node) bool {
+		if n.Type() == NodeSection && len(path) > 0 {
+			sections++
+		}
+		return true
+	})
+	if h.sections != sections {
+		t.Errorf("got %d sections, expected %d", h.sections, sections)
+	}
+
+	h = &eventHandler{}
+	const input = "# top\nsystem {\n    inactive: host-name r1;\n    name-server [ a \"b c\" ];\n    login { }\n}\nversion 1;\n"
+	if err := ParseStream("stream", input, h); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"comment # top",
+		"start system",
+		`statement system/host-name ["r1"]`,
+		`statement system/name-server ["a" "\"b c\""]`,
+		"start system/login",
+		"end system/login",
+		"end system",
+		`statement version ["1"]`,
+	}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", h.events, expected)
+	}
+}
+
+func TestParseStreamError(t *testing.T) {
+	h := &eventHandler{}
+	err := ParseStream("stream", "system {\n    host-name r1;\n", h)
+	serr, ok := err.(*SyntaxError)
+	if !ok || serr.Line != 1 || serr.Column != 1 {
+		t.Fatalf("got error %v, expected a *SyntaxError at 1:1", err)
+	}
+	expected := []string{"start system", `statement system/host-name ["r1"]`}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", h.events, expected)
+	}
+}
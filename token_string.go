@@ -4,9 +4,9 @@ package jcfg
 
 import "fmt"
 
-const _tokenType_name = "tokenErrortokenEOFtokenKeywordtokenValuetokenValueStringtokenEndStatementtokenSectionStarttokenSectionEndtokenLineCommenttokenHashCommenttokenBlockCommenttokenModifiertokenListStarttokenListEnd"
+const _tokenType_name = "tokenErrortokenEOFtokenKeywordtokenValuetokenValueStringtokenEndStatementtokenSectionStarttokenSectionEndtokenLineCommenttokenHashCommenttokenBlockCommenttokenModifiertokenListStarttokenListEndtokenAnnotationtokenNumber"
 
-var _tokenType_index = [...]uint8{10, 18, 30, 40, 56, 73, 90, 105, 121, 137, 154, 167, 181, 193}
+var _tokenType_index = [...]uint8{10, 18, 30, 40, 56, 73, 90, 105, 121, 137, 154, 167, 181, 193, 208, 219}
 
 func (i tokenType) String() string {
 	if i < 0 || i >= tokenType(len(_tokenType_index)) {
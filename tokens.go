@@ -0,0 +1,119 @@
+package jcfg
+
+//go:generate stringer -type=TokenType -output=tokentype_string.go
+
+// TokenType identifies the type of a Token.
+type TokenType int
+
+// The token types, which mirror those used internally by the lexer.
+const (
+	TokenError        TokenType = iota // An error; Tokens returns it as a *SyntaxError instead
+	TokenEOF                           // The end of the input
+	TokenKeyword                       // The keyword starting a statement
+	TokenValue                         // A value following a keyword, quoted or not, other than a TokenNumber
+	TokenValueString                   // Reserved
+	TokenEndStatement                  // The ';' or line ending that ends a statement
+	TokenSectionStart                  // The '{' starting a section
+	TokenSectionEnd                    // The '}' ending a section
+	TokenLineComment                   // A comment from "//" to the end of the line
+	TokenHashComment                   // A comment from "#" to the end of the line
+	TokenBlockComment                  // A comment between "/*" and "*/"
+	TokenModifier                      // A modifier such as "inactive" before a statement
+	TokenListStart                     // The '[' starting a list
+	TokenListEnd                       // The ']' ending a list
+	TokenAnnotation                    // A "##" annotation to the end of the line
+	TokenNumber                        // A value that is a number such as 1500, -1 or 1.5
+)
+
+// Token is a lexical token of a configuration.
+type Token struct {
+	Type  TokenType
+	Value string // text of the token exactly as in the input
+	Line  int    // line of the token, starting at 1
+	Col   int    // column of the token in bytes, starting at 1
+	Pos   int    // byte offset of the token in the input
+}
+
+// Tokens splits the configuration in input into its tokens, ending with
+// one of type TokenEOF. It suits tools such as syntax highlighters that
+// work with the text of a configuration rather than its structure. Text
+// that is not valid between tokens returns the tokens before it and a
+// *SyntaxError; whether the tokens form a valid configuration is left to
+// Parse.
+func Tokens(name, input string) ([]Token, error) {
+	var tokens []Token
+	s := NewScanner(name, input)
+	for s.Scan() {
+		tokens = append(tokens, s.Token())
+	}
+	if err := s.Err(); err != nil {
+		return tokens, err
+	}
+	return append(tokens, s.Token()), nil
+}
+
+// Scanner reads the tokens of a configuration one at a time, as Tokens
+// does all at once. Successive calls to Scan step through the tokens,
+// stopping at the end of the input or the first error.
+type Scanner struct {
+	name      string
+	input     string
+	l         *lexer
+	tok       Token
+	err       error
+	done      bool
+	line      int // line of the byte at scanned
+	lineStart int // offset of the start of line
+	scanned   int // offset up to which line endings have been counted
+}
+
+// NewScanner returns a Scanner reading the configuration in input. The
+// name is used in errors.
+func NewScanner(name, input string) *Scanner {
+	return &Scanner{name: name, input: input, l: lex(name, input), line: 1}
+}
+
+// Scan advances to the next token, which is then available from Token. It
+// returns false at the end of the input, when Token returns the TokenEOF
+// token, or on an error, which Err then returns.
+func (s *Scanner) Scan() bool {
+	if s.done {
+		return false
+	}
+	tok := s.l.nextToken()
+	// Count the line endings up to the token, "\r\n" as one.
+	for ; s.scanned < tok.pos; s.scanned++ {
+		c := s.input[s.scanned]
+		if c == '\n' || c == '\r' && (s.scanned+1 == len(s.input) || s.input[s.scanned+1] != '\n') {
+			s.line++
+			s.lineStart = s.scanned + 1
+		}
+	}
+	col := tok.pos - s.lineStart + 1
+	switch tok.typ {
+	case tokenError:
+		s.done = true
+		s.err = &SyntaxError{Name: s.name, Line: s.line, Column: col, Msg: tok.val}
+		return false
+	case tokenEOF:
+		s.done = true
+	}
+	s.tok = Token{
+		Type:  TokenType(tok.typ),
+		Value: tok.val,
+		Line:  s.line,
+		Col:   col,
+		Pos:   tok.pos,
+	}
+	return !s.done
+}
+
+// Token returns the token read by the last call to Scan.
+func (s *Scanner) Token() Token {
+	return s.tok
+}
+
+// Err returns the *SyntaxError that stopped Scan, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
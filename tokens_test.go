@@ -0,0 +1,113 @@
+package jcfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenTypeMirror(t *testing.T) {
+	// Compare against every name stringer knows of so that a token type
+	// added to only one of the two lists fails here.
+	internalCount, publicCount := len(_tokenType_index), len(_TokenType_index)
+	if internalCount != publicCount {
+		t.Fatalf("got\n\t%d token types\nexpected\n\t%d TokenTypes", internalCount, publicCount)
+	}
+	for typ := tokenType(0); int(typ) < internalCount; typ++ {
+		internal := strings.TrimPrefix(typ.String(), "token")
+		if got := strings.TrimPrefix(TokenType(typ).String(), "Token"); got != internal {
+			t.Errorf("TokenType(%d) is %s, expected Token%s", typ, TokenType(typ), internal)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	const input = "system {\r\n    host-name r1; # name\r\n  members [ a \"b c\" ];\n}"
+	tokens, err := Tokens("tokens", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Token{
+		{TokenKeyword, "system", 1, 1, 0},
+		{TokenSectionStart, "{", 1, 8, 7},
+		{TokenKeyword, "host-name", 2, 5, 14},
+		{TokenValue, "r1", 2, 15, 24},
+		{TokenEndStatement, ";", 2, 17, 26},
+		{TokenHashComment, "# name", 2, 19, 28},
+		{TokenKeyword, "members", 3, 3, 38},
+		{TokenListStart, "[", 3, 11, 46},
+		{TokenValue, "a", 3, 13, 48},
+		{TokenValue, `"b c"`, 3, 15, 50},
+		{TokenListEnd, "]", 3, 21, 56},
+		{TokenEndStatement, ";", 3, 22, 57},
+		{TokenSectionEnd, "}", 4, 1, 59},
+		{TokenEOF, "", 4, 2, 60},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", tokens, expected)
+	}
+}
+
+func TestTokensError(t *testing.T) {
+	tokens, err := Tokens("error", "system {\n    ?\n}")
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Fatalf("got error %v, expected a *SyntaxError", err)
+	}
+	if serr.Line != 2 || serr.Column != 5 {
+		t.Errorf("got error at %d:%d, expected 2:5", serr.Line, serr.Column)
+	}
+	if len(tokens) != 2 || tokens[1].Type != TokenSectionStart {
+		t.Errorf("got tokens %v before the error, expected system {", tokens)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	s := NewScanner("scanner", "mtu 1500;")
+	var got []TokenType
+	for s.Scan() {
+		got = append(got, s.Token().Type)
+	}
+	expected := []TokenType{TokenKeyword, TokenNumber, TokenEndStatement}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", got, expected)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("got error %v, expected none", err)
+	}
+	if tok := s.Token(); tok.Type != TokenEOF || tok.Pos != 9 {
+		t.Errorf("got %v after the last token, expected TokenEOF at 9", tok)
+	}
+	if s.Scan() {
+		t.Error("Scan returned true after the end of the input")
+	}
+
+	s = NewScanner("scanner", "system {\n    ?\n}")
+	for s.Scan() {
+	}
+	var serr *SyntaxError
+	if err := s.Err(); !errors.As(err, &serr) || serr.Line != 2 || serr.Column != 5 {
+		t.Errorf("got error %v, expected a *SyntaxError at 2:5", err)
+	}
+}
+
+func ExampleScanner() {
+	s := NewScanner("example", "system {\n    host-name r1; # the router\n}\n")
+	for s.Scan() {
+		tok := s.Token()
+		fmt.Printf("%d:%d %s %q\n", tok.Line, tok.Col, tok.Type, tok.Value)
+	}
+	if err := s.Err(); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// 1:1 TokenKeyword "system"
+	// 1:8 TokenSectionStart "{"
+	// 2:5 TokenKeyword "host-name"
+	// 2:15 TokenValue "r1"
+	// 2:17 TokenEndStatement ";"
+	// 2:19 TokenHashComment "# the router"
+	// 3:1 TokenSectionEnd "}"
+}
@@ -0,0 +1,112 @@
+package jcfg
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// byteUnits maps the size suffixes Junos accepts to their multiplier.
+var byteUnits = map[byte]int64{
+	'k': 1 << 10, 'K': 1 << 10,
+	'm': 1 << 20, 'M': 1 << 20,
+	'g': 1 << 30, 'G': 1 << 30,
+}
+
+// AsBytes returns the single value of the statement as a number of bytes.
+// The value is a whole number optionally followed by a "k", "m" or "g"
+// suffix, in either case, for kilobytes, megabytes and gigabytes, so
+// "size 10m;" is 10485760 bytes.
+func (v *ValueNode) AsBytes() (int64, error) {
+	if len(v.Values) != 1 {
+		return 0, fmt.Errorf("jcfg: %s: expected a single value, got %d", v.Keyword, len(v.Values))
+	}
+	s := Unquote(v.Values[0])
+	num, mult := s, int64(1)
+	if s != "" {
+		if m, ok := byteUnits[s[len(s)-1]]; ok {
+			num, mult = s[:len(s)-1], m
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("jcfg: %s: invalid byte size %q", v.Keyword, s)
+	}
+	if n > (1<<63-1)/mult {
+		return 0, fmt.Errorf("jcfg: %s: byte size %q out of range", v.Keyword, s)
+	}
+	return n * mult, nil
+}
+
+// Int returns the single value of the statement as a decimal integer, as
+// for "mtu 1500;". It returns false if the statement does not have exactly
+// one value or the value is not an integer: only a value of kind
+// ValueNumber is considered, so a quoted value such as "1500" is always a
+// string and also returns false.
+func (v *ValueNode) Int() (int64, bool) {
+	if len(v.Values) != 1 || KindOf(v.Values[0]) != ValueNumber {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v.Values[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Bool reports whether the statement is a flag, such as "disable;", that
+// is on by being present. Junos has no boolean values so a statement with
+// any values, including an empty list, is not a flag.
+func (v *ValueNode) Bool() bool {
+	return len(v.Values) == 0 && !v.List
+}
+
+// Strings returns the values of the statement as strings with any quoting
+// removed. It is the same as Unquoted.
+func (v *ValueNode) Strings() []string {
+	return v.Unquoted()
+}
+
+//go:generate stringer -type=ValueKind -trimprefix=Value -output=valuekind_string.go
+
+// ValueKind classifies a value of a statement by how it is written. The
+// kind of a value is worked out from its text when it is asked for, by
+// KindOf or Kinds, rather than stored on a ValueNode when it is parsed or
+// built. Values is an exported field that Set, Merge and callers change
+// directly, and a stored kind would then go stale.
+type ValueKind int
+
+const (
+	ValueBareword ValueKind = iota // an unquoted word such as ge-0/0/0, 10.0.0.1 or 1-10
+	ValueNumber                    // an unquoted integer or decimal such as 1500, -1 or 1.5
+	ValueString                    // a quoted string such as "uplink"
+)
+
+// KindOf returns the kind of the value s as it appears in a statement. A
+// number is a value the lexer reads as a TokenNumber: a decimal integer
+// with an optional leading '-' and an optional fraction, so "-1" and
+// "1.5" are numbers while the interface ge-0/0/0 and the address 10.0.0.1
+// are barewords.
+func KindOf(s string) ValueKind {
+	switch {
+	case isQuoted(s):
+		return ValueString
+	case isNumber(s):
+		return ValueNumber
+	}
+	return ValueBareword
+}
+
+// Kinds returns the kind of each of the values of the statement as by
+// KindOf.
+func (v *ValueNode) Kinds() []ValueKind {
+	kinds := make([]ValueKind, len(v.Values))
+	for i, s := range v.Values {
+		kinds[i] = KindOf(s)
+	}
+	return kinds
+}
+
+// isQuoted reports whether s is a quoted string.
+func isQuoted(s string) bool {
+	return len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"'
+}
@@ -0,0 +1,180 @@
+package jcfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAsBytes(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		ok       bool
+		expected int64
+	}{
+		{"size 1024;", true, 1024},
+		{"size 10k;", true, 10240},
+		{"size 10m;", true, 10485760},
+		{"size 1g;", true, 1073741824},
+		{"size 2G;", true, 2147483648},
+		{`size "5m";`, true, 5242880},
+		{"size 10x;", false, 0},
+		{"size m;", false, 0},
+		{"size -1k;", false, 0},
+		{"size 9999999999999g;", false, 0},
+		{"size;", false, 0},
+		{"size 1m 2m;", false, 0},
+	} {
+		tree, err := Parse("bytes", test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := tree.Root.Nodes[0].(*ValueNode).AsBytes()
+		switch {
+		case err == nil && !test.ok:
+			t.Errorf("%q: expected error; got %d", test.input, n)
+		case err != nil && test.ok:
+			t.Errorf("%q: unexpected error: %v", test.input, err)
+		case n != test.expected:
+			t.Errorf("%q: got %d, expected %d", test.input, n, test.expected)
+		}
+	}
+}
+
+func TestValueTypes(t *testing.T) {
+	for _, test := range []struct {
+		input   string
+		i       int64
+		ok      bool
+		b       bool
+		strings []string
+	}{
+		{"mtu 1500;", 1500, true, false, []string{"1500"}},
+		{"metric -10;", -10, true, false, []string{"-10"}},
+		{`mtu "1500";`, 0, false, false, []string{"1500"}},
+		{"preference 1.5;", 0, false, false, []string{"1.5"}},
+		{"interface ge-0/0/0;", 0, false, false, []string{"ge-0/0/0"}},
+		{"disable;", 0, false, true, []string{}},
+		{"host-name r1;", 0, false, false, []string{"r1"}},
+		{"mtu 1500 9000;", 0, false, false, []string{"1500", "9000"}},
+		{`members [ a "b c" ];`, 0, false, false, []string{"a", "b c"}},
+		{"members [ ];", 0, false, false, []string{}},
+	} {
+		tree, err := Parse("types", test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := tree.Root.Nodes[0].(*ValueNode)
+		if i, ok := v.Int(); i != test.i || ok != test.ok {
+			t.Errorf("%q: Int got %d, %v, expected %d, %v", test.input, i, ok, test.i, test.ok)
+		}
+		if b := v.Bool(); b != test.b {
+			t.Errorf("%q: Bool got %v, expected %v", test.input, b, test.b)
+		}
+		if s := v.Strings(); !reflect.DeepEqual(s, test.strings) {
+			t.Errorf("%q: Strings got\n\t%q\nexpected\n\t%q", test.input, s, test.strings)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected ValueKind
+	}{
+		{"1500", ValueNumber},
+		{"-1", ValueNumber},
+		{"0", ValueNumber},
+		{"1.5", ValueNumber},
+		{"-0.25", ValueNumber},
+		{"ge-0/0/0", ValueBareword},
+		{"10.0.0.1", ValueBareword},
+		{"10.0.0.0/8", ValueBareword},
+		{"1-10", ValueBareword},
+		{"1.-5", ValueBareword},
+		{"1.", ValueBareword},
+		{".5", ValueBareword},
+		{"-", ValueBareword},
+		{"12.3R1", ValueBareword},
+		{"10m", ValueBareword},
+		{"r1", ValueBareword},
+		{`"1500"`, ValueString},
+		{`"uplink"`, ValueString},
+	} {
+		if got := KindOf(test.value); got != test.expected {
+			t.Errorf("%q: got %v, expected %v", test.value, got, test.expected)
+		}
+	}
+}
+
+func TestKinds(t *testing.T) {
+	const input = `interfaces {
+    ge-0/0/0 {
+        mtu 1500;
+        description "to core";
+    }
+}
+protocols {
+    bgp {
+        local-preference -1;
+    }
+}
+`
+	tree, err := Parse("kinds", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		path     []string
+		expected []ValueKind
+	}{
+		{[]string{"interfaces", "ge-0/0/0", "mtu"}, []ValueKind{ValueNumber}},
+		{[]string{"interfaces", "ge-0/0/0", "description"}, []ValueKind{ValueString}},
+		{[]string{"protocols", "bgp", "local-preference"}, []ValueKind{ValueNumber}},
+	} {
+		n, ok := tree.Get(test.path...)
+		if !ok {
+			t.Fatalf("%q not found", test.path)
+		}
+		if got := n.(*ValueNode).Kinds(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: got %v, expected %v", test.path, got, test.expected)
+		}
+	}
+	n, _ := tree.Get("interfaces")
+	for _, c := range n.(*SectionNode).Nodes {
+		if c := c.(*SectionNode); KindOf(c.Keyword) != ValueBareword {
+			t.Errorf("%s: got %v, expected Bareword", c.Keyword, KindOf(c.Keyword))
+		}
+	}
+	if got := ValueKind(7).String(); got != "ValueKind(7)" {
+		t.Errorf("got %q, expected ValueKind(7)", got)
+	}
+}
+
+// TestKindsAfterSet checks that the kinds of values follow changes made to
+// a statement after it was parsed.
+func TestKindsAfterSet(t *testing.T) {
+	tree, err := Parse("kinds", "interfaces { ge-0/0/0 { mtu 1500; } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := []string{"interfaces", "ge-0/0/0", "mtu"}
+	if i, ok := tree.GetInt(path...); !ok || i != 1500 {
+		t.Errorf("GetInt: got %d, %v, expected 1500, true", i, ok)
+	}
+	if err := tree.Set(path, "jumbo"); err != nil {
+		t.Fatal(err)
+	}
+	if i, ok := tree.GetInt(path...); ok {
+		t.Errorf("GetInt after Set to a bareword: got %d, true", i)
+	}
+	if err := tree.Set(path, "9000"); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := tree.Get(path...)
+	if kinds := n.(*ValueNode).Kinds(); !reflect.DeepEqual(kinds, []ValueKind{ValueNumber}) {
+		t.Errorf("Kinds after Set: got %v, expected [Number]", kinds)
+	}
+	if i, ok := tree.GetInt(path...); !ok || i != 9000 {
+		t.Errorf("GetInt after Set: got %d, %v, expected 9000, true", i, ok)
+	}
+}
@@ -0,0 +1,104 @@
+package jcfg
+
+import (
+	"iter"
+)
+
+// WalkOptions controls which nodes WalkWithOptions visits.
+type WalkOptions struct {
+	// IncludeComments also visits comments, in the order they appear in
+	// the input. The comments attached to a statement are visited before
+	// it with its path; the comments at the end of a section follow the
+	// statements of the section with the path of the section. The
+	// Attachment of each comment tells them apart.
+	IncludeComments bool
+}
+
+// Walk traverses the tree rooted at n in pre-order, calling fn for n and
+// then for each statement below it. Comments are not visited; see
+// WalkWithOptions. path is the path to the node as used by Get, ending
+// with the node itself: the keyword of a leaf or the name of a section.
+// The root of a Tree has an empty path. Each path is newly allocated and
+// safe to retain. If fn returns false for a section, Walk does not
+// descend into that section's children.
+func Walk(n Node, fn func(path []string, n Node) bool) {
+	WalkWithOptions(n, WalkOptions{}, fn)
+}
+
+// WalkWithOptions is like Walk but with options controlling which nodes
+// are visited.
+func WalkWithOptions(n Node, opts WalkOptions, fn func(path []string, n Node) bool) {
+	walk(nil, n, opts, fn)
+}
+
+func walk(path []string, n Node, opts WalkOptions, fn func(path []string, n Node) bool) {
+	var comments []*CommentNode
+	switch n := n.(type) {
+	case *ValueNode:
+		path = appendPath(path, n.Keyword)
+		comments = n.Comments
+	case *SectionNode:
+		if n.Keyword != "" {
+			path = appendPath(path, n.name())
+		}
+		comments = n.Comments
+	}
+	if opts.IncludeComments {
+		for _, c := range comments {
+			fn(path, c)
+		}
+	}
+	if !fn(path, n) {
+		return
+	}
+	if s, ok := n.(*SectionNode); ok {
+		for _, c := range s.Nodes {
+			if c.Type() == NodeComment && !opts.IncludeComments {
+				continue
+			}
+			walk(path, c, opts, fn)
+		}
+	}
+}
+
+// Leaves returns an iterator over the leaf statements of the tree in the
+// order they appear, yielding the path of each as used by Get, ending with
+// its keyword, along with the statement. Each path is newly allocated and
+// safe to retain.
+func (t *Tree) Leaves() iter.Seq2[[]string, *ValueNode] {
+	return func(yield func([]string, *ValueNode) bool) {
+		done := false
+		Walk(t.Root, func(path []string, n Node) bool {
+			if v, ok := n.(*ValueNode); ok && !done {
+				done = !yield(path, v)
+			}
+			return !done
+		})
+	}
+}
+
+// Leaf is a leaf statement of a tree with its full path, as returned by
+// Flatten.
+type Leaf struct {
+	Path   []string // path as used by Get, ending with the keyword
+	Values []string // values with any quoting removed as by Unquote
+	Quoted bool     // whether any of the values was quoted
+}
+
+// Flatten returns every leaf statement of the tree in the order they
+// appear, with the complete path leading to each: one for each command
+// of SetCommands. A flag such as "disable;" has no values.
+func (t *Tree) Flatten() []Leaf {
+	var flat []Leaf
+	for path, v := range t.Leaves() {
+		l := Leaf{Path: path, Values: v.Unquoted()}
+		for _, s := range v.Values {
+			if isQuoted(s) {
+				l.Quoted = true
+				break
+			}
+		}
+		flat = append(flat, l)
+	}
+	return flat
+}
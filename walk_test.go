@@ -0,0 +1,195 @@
+package jcfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[NodeType]int)
+	maxDepth := 0
+	Walk(tree.Root, func(path []string, n Node) bool {
+		counts[n.Type()]++
+		if len(path) > maxDepth {
+			maxDepth = len(path)
+		}
+		return true
+	})
+	// root, system, syslog and three named sections
+	if counts[NodeSection] != 6 {
+		t.Errorf("got %d sections, expected 6", counts[NodeSection])
+	}
+	if counts[NodeValue] != 4 {
+		t.Errorf("got %d leaves, expected 4", counts[NodeValue])
+	}
+	if maxDepth != 4 {
+		t.Errorf("got max depth %d, expected 4", maxDepth)
+	}
+}
+
+func TestWalkPrune(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	Walk(tree.Root, func(_ []string, n Node) bool {
+		switch n := n.(type) {
+		case *ValueNode:
+			visited = append(visited, n.Keyword)
+		case *SectionNode:
+			visited = append(visited, n.name())
+			return n.Keyword != "file"
+		}
+		return true
+	})
+	expected := []string{"", "system", "syslog", "file messages", "file interactive-commands", `user "*"`, "any"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", visited, expected)
+	}
+}
+
+func TestWalkLeafPaths(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths [][]string
+	Walk(tree.Root, func(path []string, n Node) bool {
+		if n.Type() == NodeValue {
+			paths = append(paths, path)
+		}
+		return true
+	})
+	expected := [][]string{
+		{"system", "syslog", "file messages", "any"},
+		{"system", "syslog", "file messages", "authorization"},
+		{"system", "syslog", "file interactive-commands", "interactive-commands"},
+		{"system", "syslog", `user "*"`, "any"},
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", paths, expected)
+	}
+}
+
+func TestWalkComments(t *testing.T) {
+	const input = `/* file header */
+system {
+    # the router name
+    ## last changed by admin
+    host-name r1;
+    // end of system
+}
+# end of file
+`
+	tree, err := Parse("comments", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type visit struct {
+		path       string
+		text       string
+		attachment CommentAttachment
+	}
+	var visited []visit
+	WalkWithOptions(tree.Root, WalkOptions{IncludeComments: true}, func(path []string, n Node) bool {
+		if c, ok := n.(*CommentNode); ok {
+			visited = append(visited, visit{strings.Join(path, " "), c.Text, c.Attachment})
+		}
+		return true
+	})
+	expected := []visit{
+		{"system", "/* file header */", CommentLeading},
+		{"system host-name", "# the router name", CommentLeading},
+		{"system host-name", "## last changed by admin", CommentAnnotation},
+		{"system", "// end of system", CommentTrailing},
+		{"", "# end of file", CommentTrailing},
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("got\n\t%v\nexpected\n\t%v", visited, expected)
+	}
+
+	Walk(tree.Root, func(_ []string, n Node) bool {
+		if n.Type() == NodeComment {
+			t.Errorf("Walk visited comment %q", n)
+		}
+		return true
+	})
+}
+
+func TestLeaves(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]string)
+	for path, v := range tree.Leaves() {
+		values[strings.Join(path, " > ")] = strings.Join(v.Values, " ")
+	}
+	expected := map[string]string{
+		"system > syslog > file messages > any":                              "notice",
+		"system > syslog > file messages > authorization":                    "info",
+		"system > syslog > file interactive-commands > interactive-commands": "any",
+		`system > syslog > user "*" > any`:                                   "emergency",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", values, expected)
+	}
+
+	// Paths are safe to keep and breaking stops the iteration.
+	var paths [][]string
+	for path := range tree.Leaves() {
+		paths = append(paths, path)
+		if len(paths) == 2 {
+			break
+		}
+	}
+	first := []string{"system", "syslog", "file messages", "any"}
+	if len(paths) != 2 || !reflect.DeepEqual(paths[0], first) {
+		t.Errorf("got %q, expected two paths starting with %q", paths, first)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Flatten()
+	if len(leaves) != 4 {
+		t.Fatalf("got %d leaves, expected 4", len(leaves))
+	}
+	expected := Leaf{Path: []string{"system", "syslog", "file messages", "authorization"}, Values: []string{"info"}}
+	if !reflect.DeepEqual(leaves[1], expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", leaves[1], expected)
+	}
+	expected = Leaf{Path: []string{"system", "syslog", `user "*"`, "any"}, Values: []string{"emergency"}}
+	if !reflect.DeepEqual(leaves[3], expected) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", leaves[3], expected)
+	}
+	if n := len(tree.SetCommands()); n != len(leaves) {
+		t.Errorf("got %d leaves for %d set commands", len(leaves), n)
+	}
+
+	tree, err = Parse("flatten", `system { login { message "hello world"; } ports { console { insecure; } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedLeaves := []Leaf{
+		{Path: []string{"system", "login", "message"}, Values: []string{"hello world"}, Quoted: true},
+		{Path: []string{"system", "ports", "console", "insecure"}, Values: []string{}},
+	}
+	if leaves := tree.Flatten(); !reflect.DeepEqual(leaves, expectedLeaves) {
+		t.Errorf("got\n\t%+v\nexpected\n\t%+v", leaves, expectedLeaves)
+	}
+}
@@ -0,0 +1,214 @@
+package jcfg
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// MarshalXML implements xml.Marshaler, producing the Junos XML form of the
+// configuration wrapped in a <configuration> element regardless of the
+// start element passed in.
+//
+// Sections become nested elements named after their keyword. The values
+// identifying a section are written as a leading <name> element, so
+// "file messages { ... }" becomes <file><name>messages</name>...</file>.
+// A statement whose keyword is not a valid XML name, such as the interface
+// ge-0/0/0 or the prefix 192.0.2.0/24, is written the same way in an
+// element named after its section: the singular of the section's name if
+// it ends in "s", as in <interfaces><interface><name>ge-0/0/0</name>,
+// and otherwise the name with "-item" added, as in
+// <prefix-list-item><name>192.0.2.0/24</name>. A keyword that is a valid
+// name, such as the interface lo0, is used as it is.
+//
+// A leaf statement with a single value becomes <keyword>value</keyword>,
+// a flag becomes an empty element and a statement with several values
+// repeats the element once per value. A modifier becomes an attribute
+// named after it, such as inactive="inactive". Comments are omitted.
+//
+// The encoding/xml package always writes an empty element with a start
+// and end tag, as <disable></disable>; WriteXML writes the same elements
+// with flags as <disable/>.
+func (t *Tree) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return t.xmlElement().encode(e)
+}
+
+// WriteXML writes the Junos XML form of the configuration, as produced by
+// MarshalXML, to w with flags written as self-closing elements such as
+// <disable/>. Each element is on its own line indented by indent for each
+// level of nesting unless indent is empty, when the whole configuration
+// is written on one line.
+func (t *Tree) WriteXML(w io.Writer, indent string) error {
+	x := &xmlWriter{w: w, indent: indent}
+	x.element(t.xmlElement(), 0)
+	if indent != "" {
+		x.write("\n")
+	}
+	return x.err
+}
+
+// xmlElement is an element of the XML form of a configuration. An element
+// with neither text nor children is written empty.
+type xmlElement struct {
+	start    xml.StartElement
+	text     string
+	children []xmlElement
+}
+
+// xmlElement returns the <configuration> element holding the tree.
+func (t *Tree) xmlElement() xmlElement {
+	const name = "configuration"
+	return xmlElement{
+		start:    xml.StartElement{Name: xml.Name{Local: name}},
+		children: xmlElements(name, t.Root.Nodes),
+	}
+}
+
+// xmlElements returns the elements for the statements in nodes, which
+// are in the element named parent.
+func xmlElements(parent string, nodes []Node) []xmlElement {
+	var elems []xmlElement
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *SectionNode:
+			name, id := xmlName(parent, n.Keyword, n.Values)
+			el := xmlElement{start: xmlStart(name, n.Modifier)}
+			if id != "" {
+				el.children = append(el.children, xmlText("name", id))
+			}
+			el.children = append(el.children, xmlElements(name, n.Nodes)...)
+			elems = append(elems, el)
+		case *ValueNode:
+			name, id := xmlName(parent, n.Keyword, nil)
+			if id != "" {
+				// The whole statement identifies the element.
+				_, id = xmlName(parent, n.Keyword, n.Values)
+				el := xmlElement{start: xmlStart(name, n.Modifier)}
+				el.children = []xmlElement{xmlText("name", id)}
+				elems = append(elems, el)
+				continue
+			}
+			if len(n.Values) == 0 {
+				elems = append(elems, xmlElement{start: xmlStart(name, n.Modifier)})
+			}
+			for _, v := range n.Values {
+				elems = append(elems, xmlElement{start: xmlStart(name, n.Modifier), text: Unquote(v)})
+			}
+		}
+	}
+	return elems
+}
+
+// xmlName returns the element name for a statement with keyword and the
+// identifying values in the element named parent, along with the text of
+// its <name> element or "" if it has none.
+func xmlName(parent, keyword string, values []string) (name, id string) {
+	if validXMLName(keyword) {
+		return keyword, strings.Join(unquoteValues(values), " ")
+	}
+	switch {
+	case strings.HasSuffix(parent, "s") && !strings.HasSuffix(parent, "ss"):
+		name = strings.TrimSuffix(parent, "s")
+	default:
+		name = parent + "-item"
+	}
+	id = Unquote(keyword)
+	if len(values) > 0 {
+		id += " " + strings.Join(unquoteValues(values), " ")
+	}
+	return name, id
+}
+
+// validXMLName reports whether s can be used as the name of an element. It
+// excludes ':', which separates a namespace prefix.
+func validXMLName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// xmlStart returns the start element for a statement with the modifier, if
+// any, as an attribute.
+func xmlStart(name, modifier string) xml.StartElement {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if modifier != "" {
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: modifier}, Value: modifier}}
+	}
+	return start
+}
+
+// xmlText returns an element holding text.
+func xmlText(name, text string) xmlElement {
+	return xmlElement{start: xml.StartElement{Name: xml.Name{Local: name}}, text: text}
+}
+
+func (el xmlElement) encode(e *xml.Encoder) error {
+	if err := e.EncodeToken(el.start); err != nil {
+		return err
+	}
+	if el.text != "" {
+		if err := e.EncodeToken(xml.CharData(el.text)); err != nil {
+			return err
+		}
+	}
+	for _, c := range el.children {
+		if err := c.encode(e); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(el.start.End())
+}
+
+// xmlWriter writes elements to an io.Writer. The first error encountered
+// is kept in err and all following writes are skipped.
+type xmlWriter struct {
+	w      io.Writer
+	err    error
+	indent string
+}
+
+func (x *xmlWriter) write(s string) {
+	if x.err != nil {
+		return
+	}
+	_, x.err = io.WriteString(x.w, s)
+}
+
+// escape writes s with the characters special to XML escaped.
+func (x *xmlWriter) escape(s string) {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	x.write(b.String())
+}
+
+func (x *xmlWriter) element(el xmlElement, depth int) {
+	if depth > 0 && x.indent != "" {
+		x.write("\n" + strings.Repeat(x.indent, depth))
+	}
+	x.write("<" + el.start.Name.Local)
+	for _, a := range el.start.Attr {
+		x.write(" " + a.Name.Local + `="`)
+		x.escape(a.Value)
+		x.write(`"`)
+	}
+	if el.text == "" && len(el.children) == 0 {
+		x.write("/>")
+		return
+	}
+	x.write(">")
+	x.escape(el.text)
+	for _, c := range el.children {
+		x.element(c, depth+1)
+	}
+	if len(el.children) > 0 && x.indent != "" {
+		x.write("\n" + strings.Repeat(x.indent, depth))
+	}
+	x.write("</" + el.start.Name.Local + ">")
+}
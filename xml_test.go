@@ -0,0 +1,176 @@
+package jcfg
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type xmlTest struct {
+	name   string
+	input  string
+	result string
+}
+
+var xmlTests = []xmlTest{
+	{"empty", "", `<configuration></configuration>`},
+	{"flag", "system { ports { console insecure; } no-redirects; }",
+		`<configuration><system><ports><console>insecure</console></ports><no-redirects></no-redirects></system></configuration>`},
+	{"multiple values", "members a b;", `<configuration><members>a</members><members>b</members></configuration>`},
+	{"escaped value", `description "a < b & c";`, `<configuration><description>a &lt; b &amp; c</description></configuration>`},
+	{"modifiers", "inactive: system { protect: host-name r1; }",
+		`<configuration><system inactive="inactive"><host-name protect="protect">r1</host-name></system></configuration>`},
+	{"comments omitted", "# comment\nsystem { /* block */ ports; }", `<configuration><system><ports></ports></system></configuration>`},
+	{"identifier keywords", "interfaces { ge-0/0/0 { mtu 1500; disable; } }",
+		`<configuration><interfaces><interface><name>ge-0/0/0</name><mtu>1500</mtu><disable></disable></interface></interfaces></configuration>`},
+	{"identifier leaves", "prefix-list mgmt { 192.0.2.0/24; } commands { \"show version\" brief; }",
+		`<configuration><prefix-list><name>mgmt</name><prefix-list-item><name>192.0.2.0/24</name></prefix-list-item></prefix-list>` +
+			`<commands><command><name>show version brief</name></command></commands></configuration>`},
+}
+
+func TestMarshalXML(t *testing.T) {
+	for _, test := range xmlTests {
+		tree, err := Parse(test.name, test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := xml.Marshal(tree)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if string(b) != test.result {
+			t.Errorf("%s: got\n\t%s\nexpected\n\t%s", test.name, b, test.result)
+		}
+	}
+}
+
+func TestMarshalXMLFile(t *testing.T) {
+	const expected = `<configuration>
+  <system>
+    <syslog>
+      <file>
+        <name>messages</name>
+        <any>notice</any>
+        <authorization>info</authorization>
+      </file>
+      <file>
+        <name>interactive-commands</name>
+        <interactive-commands>any</interactive-commands>
+      </file>
+      <user>
+        <name>*</name>
+        <any>emergency</any>
+      </user>
+    </syslog>
+  </system>
+</configuration>`
+
+	tree, err := ParseFile("testdata/junos-factory.config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("got\n%s\nexpected\n%s", b, expected)
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	tree, err := Parse("xml", "interfaces { ge-0/0/0 { description \"a < b\"; disable; } }\ninactive: snmp;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		indent   string
+		expected string
+	}{
+		{"", `<configuration><interfaces><interface><name>ge-0/0/0</name><description>a &lt; b</description><disable/></interface></interfaces><snmp inactive="inactive"/></configuration>`},
+		{"  ", `<configuration>
+  <interfaces>
+    <interface>
+      <name>ge-0/0/0</name>
+      <description>a &lt; b</description>
+      <disable/>
+    </interface>
+  </interfaces>
+  <snmp inactive="inactive"/>
+</configuration>
+`},
+	} {
+		var b strings.Builder
+		if err := tree.WriteXML(&b, test.indent); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.expected {
+			t.Errorf("got\n%s\nexpected\n%s", b.String(), test.expected)
+		}
+	}
+}
+
+func TestXMLUnmarshal(t *testing.T) {
+	const input = `interfaces {
+    ge-0/0/0 {
+        mtu 1500;
+        disable;
+    }
+    xe-0/0/1.100 {
+        description "uplink & more";
+    }
+}
+policy-options {
+    prefix-list mgmt {
+        192.0.2.0/24;
+        198.51.100.0/24;
+    }
+}
+`
+	type iface struct {
+		Name        string    `xml:"name"`
+		MTU         int       `xml:"mtu"`
+		Disable     *struct{} `xml:"disable"`
+		Description string    `xml:"description"`
+	}
+	type config struct {
+		Interfaces []iface `xml:"interfaces>interface"`
+		Prefixes   []struct {
+			Name  string   `xml:"name"`
+			Items []string `xml:"prefix-list-item>name"`
+		} `xml:"policy-options>prefix-list"`
+	}
+	expected := config{
+		Interfaces: []iface{
+			{Name: "ge-0/0/0", MTU: 1500, Disable: &struct{}{}},
+			{Name: "xe-0/0/1.100", Description: "uplink & more"},
+		},
+	}
+	expected.Prefixes = append(expected.Prefixes, struct {
+		Name  string   `xml:"name"`
+		Items []string `xml:"prefix-list-item>name"`
+	}{"mgmt", []string{"192.0.2.0/24", "198.51.100.0/24"}})
+
+	tree, err := Parse("xml", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshaled, err := xml.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written strings.Builder
+	if err := tree.WriteXML(&written, "  "); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{marshaled, []byte(written.String())} {
+		var got config
+		if err := xml.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: got\n\t%+v\nexpected\n\t%+v", data, got, expected)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jcfg
+
+// Zip walks the statements of a and b together, calling fn once for each
+// path found in either tree with the node at that path in a and in b. The
+// node is nil for a tree that does not have the path. Statements are
+// matched as by Diff and visited in pre-order, so a section is visited
+// before the statements below it. Statements are visited in the order of
+// a, with those only in b placed directly after the statement they follow
+// in b, or first if they are not preceded by one that is also in a.
+// Comments are skipped.
+func Zip(a, b *Tree, fn func(path []string, an, bn Node)) {
+	zipNodes(nil, a.Root.Nodes, b.Root.Nodes, fn)
+}
+
+func zipNodes(path []string, a, b []Node, fn func(path []string, an, bn Node)) {
+	ka, kb := keyStatements(a), keyStatements(b)
+	inA := make(map[string]bool, len(ka))
+	for _, s := range ka {
+		inA[s.key] = true
+	}
+	inB := make(map[string]Node, len(kb))
+	// after holds the statements only in b keyed by the key of the last
+	// statement before them that is also in a, or "" if there is none.
+	after := make(map[string][]Node)
+	prev := ""
+	for _, s := range kb {
+		inB[s.key] = s.node
+		if inA[s.key] {
+			prev = s.key
+		} else {
+			after[prev] = append(after[prev], s.node)
+		}
+	}
+	zipOnly := func(key string) {
+		for _, n := range after[key] {
+			zipNode(path, nil, n, fn)
+		}
+	}
+	zipOnly("")
+	for _, s := range ka {
+		zipNode(path, s.node, inB[s.key], fn)
+		if inB[s.key] != nil {
+			zipOnly(s.key)
+		}
+	}
+}
+
+// zipNode calls fn for the pair of matching statements an and bn, either
+// of which may be nil, and then for the statements below them.
+func zipNode(path []string, an, bn Node, fn func(path []string, an, bn Node)) {
+	n := an
+	if n == nil {
+		n = bn
+	}
+	switch n := n.(type) {
+	case *ValueNode:
+		fn(appendPath(path, n.Keyword), an, bn)
+		return
+	case *SectionNode:
+		path = appendPath(path, n.name())
+	}
+	fn(path, an, bn)
+	var ac, bc []Node
+	if an != nil {
+		ac = an.(*SectionNode).Nodes
+	}
+	if bn != nil {
+		bc = bn.(*SectionNode).Nodes
+	}
+	zipNodes(path, ac, bc, fn)
+}
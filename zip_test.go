@@ -0,0 +1,68 @@
+package jcfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestZip(t *testing.T) {
+	a, err := Parse("a", `system {
+    host-name r1;
+    services {
+        telnet;
+    }
+    time-zone UTC;
+}
+snmp {
+    community public;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("b", `system {
+    domain-name example.com;
+    host-name r2;
+    services {
+        ssh;
+    }
+    time-zone UTC;
+    ntp {
+        server 192.0.2.1;
+    }
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	Zip(a, b, func(path []string, an, bn Node) {
+		got = append(got, strings.Join(path, " ")+": "+zipString(an)+" | "+zipString(bn))
+	})
+	expected := []string{
+		"system: system | system",
+		"system domain-name: - | domain-name example.com;",
+		"system host-name: host-name r1; | host-name r2;",
+		"system services: services | services",
+		"system services ssh: - | ssh;",
+		"system services telnet: telnet; | -",
+		"system time-zone: time-zone UTC; | time-zone UTC;",
+		"system ntp: - | ntp",
+		"system ntp server: - | server 192.0.2.1;",
+		"snmp: snmp | -",
+		"snmp community: community public; | -",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n\t%q\nexpected\n\t%q", got, expected)
+	}
+}
+
+// zipString returns a short description of n for comparing Zip results.
+func zipString(n Node) string {
+	switch n := n.(type) {
+	case nil:
+		return "-"
+	case *SectionNode:
+		return n.name()
+	}
+	return n.String()
+}